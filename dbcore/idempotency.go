@@ -0,0 +1,152 @@
+package dbcore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nk-bm/gocore/gostore"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// idempotencyCleanupInterval is how often expired keys are deleted
+const idempotencyCleanupInterval = time.Minute
+
+// PostgresIdempotencyStore keeps idempotent responses in Postgres so that retries reaching another replica
+// are replayed. Keys are claimed by an upsert, the database clock is used for all replicas
+type PostgresIdempotencyStore struct {
+	db    *gorm.DB
+	table string
+
+	mu          sync.Mutex
+	lastCleanup time.Time
+}
+
+// NewPostgresIdempotencyStore uses the table "<prefix>_idempotency_keys" created by NewIdempotencyMigrator
+func NewPostgresIdempotencyStore(db *gorm.DB, tablePrefix string) *PostgresIdempotencyStore {
+	return &PostgresIdempotencyStore{db: db, table: prefixedTable(tablePrefix, "idempotency_keys")}
+}
+
+// NewIdempotencyMigrator returns the migrator of the PostgresIdempotencyStore table. Its versions are kept
+// in "<prefix>_idempotency_keys_migrations", apart from the migrations of the service
+func NewIdempotencyMigrator(db *gorm.DB, logger *zap.Logger, tablePrefix string) *Migrator {
+	table := prefixedTable(tablePrefix, "idempotency_keys")
+	return NewMigrator(db, logger, table, Migrations(
+		Migration{
+			Version:     1,
+			Description: "create " + table,
+			Up: func(db *gorm.DB) error {
+				// Expired keys are deleted by expires_at on the request path
+				return db.Exec(`
+					CREATE TABLE IF NOT EXISTS ` + table + ` (
+						key TEXT PRIMARY KEY,
+						fingerprint TEXT NOT NULL,
+						status INTEGER NOT NULL DEFAULT 0,
+						header JSONB,
+						body BYTEA,
+						locked_at TIMESTAMPTZ NOT NULL,
+						expires_at TIMESTAMPTZ NOT NULL
+					);
+					CREATE INDEX IF NOT EXISTS ` + table + `_expires_at_idx ON ` + table + ` (expires_at)
+				`).Error
+			},
+			Down: func(db *gorm.DB) error {
+				return db.Exec(`DROP TABLE IF EXISTS ` + table).Error
+			},
+		},
+	))
+}
+
+func (s *PostgresIdempotencyStore) Begin(ctx context.Context, key, fingerprint string, lockTimeout, ttl time.Duration) (*gostore.IdempotencyRecord, bool, error) {
+	db := s.db.WithContext(ctx)
+	defer s.cleanup(ctx)
+
+	// The existing record may be released between the upsert and the select, then the key is claimed again
+	for range 3 {
+		var claimed []string
+		err := db.Raw(`
+			INSERT INTO `+s.table+` AS t (key, fingerprint, status, locked_at, expires_at)
+			VALUES (?, ?, 0, now(), now() + make_interval(secs => ?))
+			ON CONFLICT (key) DO UPDATE
+			SET fingerprint = excluded.fingerprint, status = 0, header = NULL, body = NULL,
+				locked_at = excluded.locked_at, expires_at = excluded.expires_at
+			WHERE t.expires_at < now() OR (t.status = 0 AND t.locked_at < now() - make_interval(secs => ?))
+			RETURNING key
+		`, key, fingerprint, ttl.Seconds(), lockTimeout.Seconds()).Scan(&claimed).Error
+		if err != nil {
+			return nil, false, fmt.Errorf("claim idempotency key: %w", err)
+		}
+		if len(claimed) > 0 {
+			return nil, true, nil
+		}
+
+		var (
+			record gostore.IdempotencyRecord
+			header []byte
+		)
+		row := db.Raw(`SELECT fingerprint, status, header, body FROM `+s.table+` WHERE key = ?`, key).Row()
+		err = row.Scan(&record.Fingerprint, &record.Status, &header, &record.Body)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("get idempotency key: %w", err)
+		}
+		if len(header) > 0 {
+			if err := json.Unmarshal(header, &record.Header); err != nil {
+				return nil, false, fmt.Errorf("decode idempotency headers: %w", err)
+			}
+		}
+		return &record, false, nil
+	}
+	return nil, false, fmt.Errorf("claim idempotency key: key is released concurrently")
+}
+
+func (s *PostgresIdempotencyStore) Complete(ctx context.Context, key, fingerprint string, record gostore.IdempotencyRecord, ttl time.Duration) error {
+	header := record.Header
+	if header == nil {
+		header = http.Header{}
+	}
+	encoded, err := json.Marshal(header)
+	if err != nil {
+		return fmt.Errorf("encode idempotency headers: %w", err)
+	}
+	err = s.db.WithContext(ctx).Exec(`
+		UPDATE `+s.table+`
+		SET status = ?, header = ?, body = ?, expires_at = now() + make_interval(secs => ?)
+		WHERE key = ? AND fingerprint = ? AND status = 0
+	`, record.Status, string(encoded), record.Body, ttl.Seconds(), key, fingerprint).Error
+	if err != nil {
+		return fmt.Errorf("complete idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresIdempotencyStore) Release(ctx context.Context, key, fingerprint string) error {
+	err := s.db.WithContext(ctx).Exec(`DELETE FROM `+s.table+` WHERE key = ? AND fingerprint = ? AND status = 0`, key, fingerprint).Error
+	if err != nil {
+		return fmt.Errorf("release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// cleanup deletes expired keys at most once per idempotencyCleanupInterval
+func (s *PostgresIdempotencyStore) cleanup(ctx context.Context) {
+	now := time.Now()
+	s.mu.Lock()
+	if now.Sub(s.lastCleanup) < idempotencyCleanupInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastCleanup = now
+	s.mu.Unlock()
+
+	// A failed cleanup is retried after the interval and does not affect the request
+	_ = s.db.WithContext(ctx).Exec(`DELETE FROM ` + s.table + ` WHERE expires_at < now()`).Error
+}
@@ -0,0 +1,81 @@
+package dbcore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nk-bm/gocore/goutils"
+	"go.uber.org/zap"
+	gormlogger "gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+)
+
+// GormLogger writes GORM logs to zap. Queries are logged at debug level,
+// slow queries at warn and failed queries at error. Request fields from
+// goutils.LogFieldsFromContext are added when the query runs with db.WithContext(ctx)
+type GormLogger struct {
+	logger        *zap.Logger
+	level         gormlogger.LogLevel
+	SlowThreshold time.Duration
+}
+
+func NewGormLogger(logger *zap.Logger) *GormLogger {
+	return &GormLogger{
+		logger:        logger,
+		level:         gormlogger.Info,
+		SlowThreshold: 200 * time.Millisecond,
+	}
+}
+
+func (l *GormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	clone := *l
+	clone.level = level
+	return &clone
+}
+
+func (l *GormLogger) Info(ctx context.Context, msg string, data ...any) {
+	if l.level >= gormlogger.Info {
+		l.withContext(ctx).Info(fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *GormLogger) Warn(ctx context.Context, msg string, data ...any) {
+	if l.level >= gormlogger.Warn {
+		l.withContext(ctx).Warn(fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *GormLogger) Error(ctx context.Context, msg string, data ...any) {
+	if l.level >= gormlogger.Error {
+		l.withContext(ctx).Error(fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	logger := l.withContext(ctx)
+	switch {
+	case err != nil && l.level >= gormlogger.Error && !errors.Is(err, gormlogger.ErrRecordNotFound):
+		sql, rows := fc()
+		logger.Error("Query failed", zap.Error(err), zap.Duration("elapsed", elapsed), zap.String("sql", sql), zap.Int64("rows", rows), zap.String("source", utils.FileWithLineNum()))
+	case l.SlowThreshold > 0 && elapsed > l.SlowThreshold && l.level >= gormlogger.Warn:
+		sql, rows := fc()
+		logger.Warn("Slow query", zap.Duration("elapsed", elapsed), zap.Duration("threshold", l.SlowThreshold), zap.String("sql", sql), zap.Int64("rows", rows), zap.String("source", utils.FileWithLineNum()))
+	case l.level >= gormlogger.Info && l.logger.Core().Enabled(zap.DebugLevel):
+		sql, rows := fc()
+		logger.Debug("Query", zap.Duration("elapsed", elapsed), zap.String("sql", sql), zap.Int64("rows", rows), zap.String("source", utils.FileWithLineNum()))
+	}
+}
+
+func (l *GormLogger) withContext(ctx context.Context) *zap.Logger {
+	if fields := goutils.LogFieldsFromContext(ctx); len(fields) > 0 {
+		return l.logger.With(fields...)
+	}
+	return l.logger
+}
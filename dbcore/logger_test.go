@@ -0,0 +1,38 @@
+package dbcore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nk-bm/gocore/goutils"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestGormLoggerRequestFields(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := NewGormLogger(zap.New(core))
+	ctx := goutils.ContextWithLogFields(context.Background(), zap.String("request_id", "req-1"))
+
+	sql := func() (string, int64) { return "SELECT 1", 1 }
+	logger.Trace(ctx, time.Now(), sql, nil)
+	logger.Trace(ctx, time.Now(), sql, errors.New("connection refused"))
+	logger.Trace(ctx, time.Now().Add(-time.Second), sql, nil)
+
+	entries := logs.All()
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+	wantLevels := []zapcore.Level{zapcore.DebugLevel, zapcore.ErrorLevel, zapcore.WarnLevel}
+	for i, entry := range entries {
+		if entry.Level != wantLevels[i] {
+			t.Errorf("entry %d level = %v, want %v", i, entry.Level, wantLevels[i])
+		}
+		if got := entry.ContextMap()["request_id"]; got != "req-1" {
+			t.Errorf("entry %d request_id = %v", i, got)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package dbcore
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// Collector exposes the connection pool stats of the client as go_sql_* metrics labelled by db_name.
+// The client must be connected
+func (c *PostgresClient) Collector() prometheus.Collector {
+	return collectors.NewDBStatsCollector(c.sqlDB, c.config.DBName)
+}
+
+// migratorCollector exposes Migrator.Status
+type migratorCollector struct {
+	migrator *Migrator
+
+	latest, applied, pending, lastRun, lastSuccess *prometheus.Desc
+}
+
+// Collector exposes the state of the last Run as migrations_* metrics labelled by table_prefix
+func (m *Migrator) Collector() prometheus.Collector {
+	labels := prometheus.Labels{"table_prefix": m.TablePrefix}
+	return &migratorCollector{
+		migrator:    m,
+		latest:      prometheus.NewDesc("migrations_latest_version", "Highest version of the defined migrations.", nil, labels),
+		applied:     prometheus.NewDesc("migrations_applied_version", "Highest applied migration version.", nil, labels),
+		pending:     prometheus.NewDesc("migrations_pending", "Number of migrations not applied yet.", nil, labels),
+		lastRun:     prometheus.NewDesc("migrations_last_run_timestamp_seconds", "Time of the last migration run.", nil, labels),
+		lastSuccess: prometheus.NewDesc("migrations_last_run_success", "Whether the last migration run succeeded.", nil, labels),
+	}
+}
+
+func (c *migratorCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.latest
+	ch <- c.applied
+	ch <- c.pending
+	ch <- c.lastRun
+	ch <- c.lastSuccess
+}
+
+func (c *migratorCollector) Collect(ch chan<- prometheus.Metric) {
+	status := c.migrator.Status()
+	if status.LastRun.IsZero() {
+		return
+	}
+	success := 0.0
+	if status.LastError == nil {
+		success = 1
+	}
+	ch <- prometheus.MustNewConstMetric(c.latest, prometheus.GaugeValue, float64(status.LatestVersion))
+	ch <- prometheus.MustNewConstMetric(c.applied, prometheus.GaugeValue, float64(status.AppliedVersion))
+	ch <- prometheus.MustNewConstMetric(c.pending, prometheus.GaugeValue, float64(status.Pending))
+	ch <- prometheus.MustNewConstMetric(c.lastRun, prometheus.GaugeValue, float64(status.LastRun.Unix()))
+	ch <- prometheus.MustNewConstMetric(c.lastSuccess, prometheus.GaugeValue, success)
+}
@@ -1,9 +1,12 @@
 package dbcore
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -35,6 +38,18 @@ type Migrator struct {
 	Logger      *zap.Logger
 	TablePrefix string
 	Migrations  []Migration
+
+	mu     sync.Mutex
+	status MigrationStatus
+}
+
+// MigrationStatus описывает результат последнего запуска миграций
+type MigrationStatus struct {
+	LatestVersion  int
+	AppliedVersion int
+	Pending        int
+	LastRun        time.Time
+	LastError      error
 }
 
 // New создает новый менеджер миграций для сервиса
@@ -74,8 +89,53 @@ func (m *Migrator) AddDownMigration(description string, down MigrationFunc) {
 	m.AddMigration(description, m.EmptyFunc, down)
 }
 
+// Status возвращает состояние миграций после последнего запуска Run
+func (m *Migrator) Status() MigrationStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.status
+}
+
+// HealthCheck возвращает ошибку, пока миграции не применены успешно
+func (m *Migrator) HealthCheck(context.Context) error {
+	status := m.Status()
+	switch {
+	case status.LastRun.IsZero():
+		return fmt.Errorf("migrations have not run")
+	case status.LastError != nil:
+		return fmt.Errorf("migrations failed: %w", status.LastError)
+	case status.Pending > 0:
+		return fmt.Errorf("%d migrations are pending", status.Pending)
+	}
+	return nil
+}
+
 // Run запускает все миграции для сервиса
 func (m *Migrator) Run() error {
+	appliedVersion, err := m.run()
+
+	latestVersion, pending := 0, 0
+	for _, migration := range m.Migrations {
+		latestVersion = max(latestVersion, migration.Version)
+		if migration.Version > appliedVersion {
+			pending++
+		}
+	}
+
+	m.mu.Lock()
+	m.status = MigrationStatus{
+		LatestVersion:  latestVersion,
+		AppliedVersion: appliedVersion,
+		Pending:        pending,
+		LastRun:        time.Now(),
+		LastError:      err,
+	}
+	m.mu.Unlock()
+	return err
+}
+
+// run применяет миграции и возвращает максимальную применённую версию
+func (m *Migrator) run() (int, error) {
 	err := m.DB.Exec(`
 		CREATE TABLE IF NOT EXISTS ` + m.TableName() + ` (
 			version INT PRIMARY KEY,
@@ -83,19 +143,23 @@ func (m *Migrator) Run() error {
 		)
 	`).Error
 	if err != nil {
-		return fmt.Errorf("failed to create migrations table: %w", err)
+		return 0, fmt.Errorf("failed to create migrations table: %w", err)
 	}
 
 	// Получаем все применённые миграции
 	var appliedMigrations []MigrationRecord
 	if err := m.DB.Table(m.TableName()).Find(&appliedMigrations).Error; err != nil {
-		return fmt.Errorf("failed to get applied migrations: %w", err)
+		return 0, fmt.Errorf("failed to get applied migrations: %w", err)
 	}
 
 	// Создаем map для быстрой проверки
 	appliedVersions := make(map[int]bool)
+	appliedVersion := 0
 	for _, record := range appliedMigrations {
 		appliedVersions[record.Version] = record.Applied
+		if record.Applied {
+			appliedVersion = max(appliedVersion, record.Version)
+		}
 	}
 
 	// Сортируем миграции по версии
@@ -134,15 +198,16 @@ func (m *Migrator) Run() error {
 		})
 
 		if err != nil {
-			return err
+			return appliedVersion, err
 		}
+		appliedVersion = max(appliedVersion, migration.Version)
 
 		m.Logger.Info("Migration applied successfully",
 			zap.String("table_prefix", m.TablePrefix),
 			zap.Int("version", migration.Version))
 	}
 
-	return nil
+	return appliedVersion, nil
 }
 
 func (m *Migrator) EmptyFunc(db *gorm.DB) error {
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	_ "github.com/lib/pq"
+	"go.uber.org/zap"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/schema"
@@ -16,9 +17,11 @@ type PostgresConfig struct {
 	Host        string `env:"POSTGRES_HOST; default:localhost"`
 	Port        int    `env:"POSTGRES_PORT; default:5432"`
 	User        string `env:"POSTGRES_USER; default:postgres"`
-	Password    string `env:"POSTGRES_PASSWORD; default:postgres"`
+	Password    string `env:"POSTGRES_PASSWORD; default:postgres; secret"`
 	DBName      string `env:"POSTGRES_DB; default:postgres"`
 	TablePrefix string `env:"POSTGRES_TABLE_PREFIX; default:"`
+	// DisableTracing turns off query spans, they are no-ops unless a tracer provider is configured
+	DisableTracing bool `env:"POSTGRES_DISABLE_TRACING; default:false"`
 }
 
 func (c *PostgresConfig) DSN() string {
@@ -30,14 +33,21 @@ func (c *PostgresConfig) DSN() string {
 
 type PostgresClient struct {
 	config PostgresConfig
+	logger *zap.Logger
 
 	gormDB *gorm.DB
 	sqlDB  *sql.DB
 }
 
 func NewPostgresClient(config PostgresConfig) *PostgresClient {
+	return NewPostgresClientWithLogger(config, zap.L())
+}
+
+// NewPostgresClientWithLogger creates a client that writes GORM logs to logger
+func NewPostgresClientWithLogger(config PostgresConfig, logger *zap.Logger) *PostgresClient {
 	return &PostgresClient{
 		config: config,
+		logger: logger,
 	}
 }
 
@@ -65,10 +75,16 @@ func (c *PostgresClient) Connect() error {
 		NamingStrategy: schema.NamingStrategy{
 			TablePrefix: c.config.TablePrefix,
 		},
+		Logger: NewGormLogger(c.logger),
 	})
 	if err != nil {
 		return fmt.Errorf("open gorm database connection: %w", err)
 	}
+	if !c.config.DisableTracing {
+		if err := c.gormDB.Use(NewTracingPlugin(c.config.DBName)); err != nil {
+			return fmt.Errorf("register tracing plugin: %w", err)
+		}
+	}
 
 	return nil
 }
@@ -77,6 +93,11 @@ func (c *PostgresClient) Close() error {
 	return c.sqlDB.Close()
 }
 
+// Ping checks the database connection, it is used as a health check
+func (c *PostgresClient) Ping(ctx context.Context) error {
+	return c.sqlDB.PingContext(ctx)
+}
+
 func (c *PostgresClient) GormDB() *gorm.DB {
 	return c.gormDB
 }
@@ -0,0 +1,151 @@
+package dbcore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nk-bm/gocore/gostore"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// rateLimitCleanupInterval is how often expired keys are deleted
+const rateLimitCleanupInterval = time.Minute
+
+// PostgresRateLimitStore keeps rate limit state in Postgres so that replicas share limits.
+// The state of a key is updated under a row lock and the database clock is used for all replicas
+type PostgresRateLimitStore struct {
+	db    *gorm.DB
+	table string
+
+	mu          sync.Mutex
+	lastCleanup time.Time
+}
+
+// NewPostgresRateLimitStore uses the table "<prefix>_rate_limits" created by NewRateLimitMigrator
+func NewPostgresRateLimitStore(db *gorm.DB, tablePrefix string) *PostgresRateLimitStore {
+	return &PostgresRateLimitStore{db: db, table: prefixedTable(tablePrefix, "rate_limits")}
+}
+
+// NewRateLimitMigrator returns the migrator of the PostgresRateLimitStore table. Its versions are kept
+// in "<prefix>_rate_limits_migrations", apart from the migrations of the service
+func NewRateLimitMigrator(db *gorm.DB, logger *zap.Logger, tablePrefix string) *Migrator {
+	table := prefixedTable(tablePrefix, "rate_limits")
+	return NewMigrator(db, logger, table, Migrations(
+		Migration{
+			Version:     1,
+			Description: "create " + table,
+			Up: func(db *gorm.DB) error {
+				// The state is lost on a crash, which only resets the limits
+				return db.Exec(`
+					CREATE UNLOGGED TABLE IF NOT EXISTS ` + table + ` (
+						key TEXT PRIMARY KEY,
+						tokens DOUBLE PRECISION NOT NULL DEFAULT 0,
+						current_count INTEGER NOT NULL DEFAULT 0,
+						previous_count INTEGER NOT NULL DEFAULT 0,
+						window_start TIMESTAMPTZ,
+						updated_at TIMESTAMPTZ,
+						expires_at TIMESTAMPTZ NOT NULL
+					);
+					CREATE INDEX IF NOT EXISTS ` + table + `_expires_at_idx ON ` + table + ` (expires_at)
+				`).Error
+			},
+			Down: func(db *gorm.DB) error {
+				return db.Exec(`DROP TABLE IF EXISTS ` + table).Error
+			},
+		},
+	))
+}
+
+func (s *PostgresRateLimitStore) Take(ctx context.Context, key string, limit gostore.RateLimit) (gostore.RateLimitResult, error) {
+	var result gostore.RateLimitResult
+	var now time.Time
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`INSERT INTO `+s.table+` (key, expires_at) VALUES (?, now()) ON CONFLICT (key) DO NOTHING`, key).Error
+		if err != nil {
+			return err
+		}
+
+		var (
+			state                  gostore.RateLimitState
+			windowStart, updatedAt sql.NullTime
+		)
+		row := tx.Raw(`
+			SELECT tokens, current_count, previous_count, window_start, updated_at, clock_timestamp()
+			FROM `+s.table+` WHERE key = ? FOR UPDATE
+		`, key).Row()
+		if err := row.Scan(&state.Tokens, &state.Current, &state.Previous, &windowStart, &updatedAt, &now); err != nil {
+			return err
+		}
+		state.WindowStart = windowStart.Time
+		state.UpdatedAt = updatedAt.Time
+
+		result = limit.Take(&state, now)
+		return tx.Exec(`
+			UPDATE `+s.table+`
+			SET tokens = ?, current_count = ?, previous_count = ?, window_start = ?, updated_at = ?, expires_at = ?
+			WHERE key = ?
+		`, state.Tokens, state.Current, state.Previous, state.WindowStart, state.UpdatedAt, now.Add(limit.TTL()), key).Error
+	})
+	if err != nil {
+		return gostore.RateLimitResult{}, fmt.Errorf("take rate limit: %w", err)
+	}
+
+	s.cleanup(ctx, now)
+	return result, nil
+}
+
+func (s *PostgresRateLimitStore) Peek(ctx context.Context, key string, limit gostore.RateLimit) (gostore.RateLimitResult, error) {
+	var (
+		tokens                 sql.NullFloat64
+		current, previous      sql.NullInt64
+		windowStart, updatedAt sql.NullTime
+		now                    time.Time
+	)
+	// A missing or expired key is read as empty state, as if cleanup had deleted it
+	row := s.db.WithContext(ctx).Raw(`
+		SELECT t.tokens, t.current_count, t.previous_count, t.window_start, t.updated_at, clock.ts
+		FROM (SELECT clock_timestamp() AS ts) clock
+		LEFT JOIN `+s.table+` t ON t.key = ? AND t.expires_at >= clock.ts
+	`, key).Row()
+	if err := row.Scan(&tokens, &current, &previous, &windowStart, &updatedAt, &now); err != nil {
+		return gostore.RateLimitResult{}, fmt.Errorf("peek rate limit: %w", err)
+	}
+
+	state := gostore.RateLimitState{
+		Tokens:      tokens.Float64,
+		Current:     int(current.Int64),
+		Previous:    int(previous.Int64),
+		WindowStart: windowStart.Time,
+		UpdatedAt:   updatedAt.Time,
+	}
+	return limit.Peek(state, now), nil
+}
+
+// cleanup deletes expired keys at most once per rateLimitCleanupInterval
+func (s *PostgresRateLimitStore) cleanup(ctx context.Context, now time.Time) {
+	s.mu.Lock()
+	if now.Sub(s.lastCleanup) < rateLimitCleanupInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastCleanup = now
+	s.mu.Unlock()
+
+	// A failed cleanup is retried after the interval and does not affect the request
+	_ = s.db.WithContext(ctx).Exec(`DELETE FROM ` + s.table + ` WHERE expires_at < now()`).Error
+}
+
+// prefixedTable names tables like Migrator.TableName does
+func prefixedTable(prefix, name string) string {
+	prefix = strings.ToLower(strings.ReplaceAll(prefix, " ", "_"))
+	if prefix == "" {
+		return name
+	}
+	return prefix + "_" + name
+}
@@ -0,0 +1,89 @@
+package dbcore
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const (
+	tracerName  = "github.com/nk-bm/gocore/dbcore"
+	spanKey     = "gocore:span"
+	tracingName = "gocore:tracing"
+)
+
+// TracingPlugin creates a client span for every GORM operation as a child of the span
+// in the statement context, so queries run with db.WithContext(ctx) join the request trace
+type TracingPlugin struct {
+	tracer trace.Tracer
+	dbName string
+}
+
+func NewTracingPlugin(dbName string) *TracingPlugin {
+	return &TracingPlugin{tracer: otel.Tracer(tracerName), dbName: dbName}
+}
+
+func (p *TracingPlugin) Name() string {
+	return tracingName
+}
+
+func (p *TracingPlugin) Initialize(db *gorm.DB) error {
+	return errors.Join(
+		db.Callback().Create().Before("gorm:create").Register(tracingName+":before_create", p.before("create")),
+		db.Callback().Create().After("gorm:create").Register(tracingName+":after_create", p.after),
+		db.Callback().Query().Before("gorm:query").Register(tracingName+":before_query", p.before("select")),
+		db.Callback().Query().After("gorm:query").Register(tracingName+":after_query", p.after),
+		db.Callback().Update().Before("gorm:update").Register(tracingName+":before_update", p.before("update")),
+		db.Callback().Update().After("gorm:update").Register(tracingName+":after_update", p.after),
+		db.Callback().Delete().Before("gorm:delete").Register(tracingName+":before_delete", p.before("delete")),
+		db.Callback().Delete().After("gorm:delete").Register(tracingName+":after_delete", p.after),
+		db.Callback().Row().Before("gorm:row").Register(tracingName+":before_row", p.before("row")),
+		db.Callback().Row().After("gorm:row").Register(tracingName+":after_row", p.after),
+		db.Callback().Raw().Before("gorm:raw").Register(tracingName+":before_raw", p.before("raw")),
+		db.Callback().Raw().After("gorm:raw").Register(tracingName+":after_raw", p.after),
+	)
+}
+
+func (p *TracingPlugin) before(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx, span := p.tracer.Start(db.Statement.Context, "db."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system", "postgresql"),
+				attribute.String("db.namespace", p.dbName),
+				attribute.String("db.operation.name", operation),
+			),
+		)
+		db.Statement.Context = ctx
+		db.InstanceSet(spanKey, span)
+	}
+}
+
+func (p *TracingPlugin) after(db *gorm.DB) {
+	value, ok := db.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	// SQL keeps placeholders, so values never reach the trace
+	span.SetAttributes(
+		attribute.String("db.query.text", db.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", db.RowsAffected),
+	)
+	if db.Statement.Table != "" {
+		span.SetAttributes(attribute.String("db.collection.name", db.Statement.Table))
+	}
+	if err := db.Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
@@ -0,0 +1,92 @@
+package dbcore
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type tracedOrder struct {
+	ID     int
+	Status string
+}
+
+// newTracedTestDB returns a dry run database, statements are built and traced without a server
+func newTracedTestDB(t *testing.T) (*gorm.DB, *tracetest.InMemoryExporter) {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+
+	db, err := gorm.Open(postgres.Open("host=localhost dbname=app"), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Use(NewTracingPlugin("app")); err != nil {
+		t.Fatal(err)
+	}
+	return db, exporter
+}
+
+func spanAttribute(span tracetest.SpanStub, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestTracingPluginQuerySpan(t *testing.T) {
+	db, exporter := newTracedTestDB(t)
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "request")
+	var orders []tracedOrder
+	db.WithContext(ctx).Where("status = ?", "paid").Find(&orders)
+	parent.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want the query and the request", len(spans))
+	}
+	span := spans[0]
+	if span.Name != "db.select" || span.SpanKind != trace.SpanKindClient {
+		t.Errorf("span = %q of kind %v, want a client span db.select", span.Name, span.SpanKind)
+	}
+	if span.Parent.SpanID() != parent.SpanContext().SpanID() {
+		t.Error("query span is not a child of the span in the statement context")
+	}
+	query := spanAttribute(span, "db.query.text").AsString()
+	if !strings.Contains(query, "status = $1") || strings.Contains(query, "paid") {
+		t.Errorf("db.query.text = %q, want placeholders without values", query)
+	}
+	if got := spanAttribute(span, "db.collection.name").AsString(); got != "traced_orders" {
+		t.Errorf("db.collection.name = %q", got)
+	}
+	if got := spanAttribute(span, "db.namespace").AsString(); got != "app" {
+		t.Errorf("db.namespace = %q", got)
+	}
+}
+
+func TestTracingPluginRecordsErrors(t *testing.T) {
+	db, exporter := newTracedTestDB(t)
+	err := db.Delete(&tracedOrder{}).Error
+	if !errors.Is(err, gorm.ErrMissingWhereClause) {
+		t.Fatalf("Delete error = %v, want ErrMissingWhereClause", err)
+	}
+
+	span := exporter.GetSpans()[0]
+	if span.Name != "db.delete" || span.Status.Code != codes.Error {
+		t.Errorf("span = %q with status %v, want a failed db.delete", span.Name, span.Status)
+	}
+}
@@ -0,0 +1,31 @@
+package env_test
+
+import (
+	"testing"
+
+	"github.com/nk-bm/gocore/dbcore"
+	"github.com/nk-bm/gocore/env"
+	"github.com/nk-bm/gocore/gincore"
+)
+
+// Теги существующих конфигов должны сохранять смысл при разборе по грамматике тегов
+func TestExistingConfigTags(t *testing.T) {
+	var postgres dbcore.PostgresConfig
+	if err := env.LoadEnv(&postgres); err != nil {
+		t.Fatal(err)
+	}
+	if postgres.Host != "localhost" || postgres.Port != 5432 || postgres.Password != "postgres" || postgres.TablePrefix != "" {
+		t.Fatalf("unexpected PostgresConfig defaults %+v", postgres)
+	}
+	if _, ok := env.Dump(&postgres)["POSTGRES_PASSWORD"]; ok {
+		t.Fatal("POSTGRES_PASSWORD is not secret")
+	}
+
+	var gin gincore.Config
+	if err := env.LoadEnv(&gin); err != nil {
+		t.Fatal(err)
+	}
+	if gin.APIPath != "/api/v1" || gin.Port != 8080 || gin.Host != "0.0.0.0" {
+		t.Fatalf("unexpected gincore.Config defaults %+v", gin)
+	}
+}
@@ -0,0 +1,181 @@
+package env
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+)
+
+const secretMask = "******"
+
+// FieldInfo описывает одно поле конфигурации
+type FieldInfo struct {
+	Field    string `json:"field"`
+	Variable string `json:"variable"`
+	Type     string `json:"type"`
+	Default  string `json:"default"`
+	Value    string `json:"value"`
+	Source   string `json:"source"`
+	// Required отмечает переменные с опцией required, без них загрузка завершается ошибкой
+	Required bool `json:"required"`
+	Secret   bool `json:"secret"`
+}
+
+// Description — результат Describe
+type Description []FieldInfo
+
+// Describe перечисляет все поля config с тегом env и их текущие значения. Значения секретов маскируются
+func Describe(config any) (Description, error) {
+	return DescribeWithOptions(config, LoadOptions{})
+}
+
+// DescribeWithOptions работает как Describe, но определяет источники значений с заданными LoadOptions
+func DescribeWithOptions(config any, opts LoadOptions) (Description, error) {
+	var description Description
+	err := walkFields(reflect.Indirect(reflect.ValueOf(config)), "", "", func(path string, field reflect.StructField, value reflect.Value, tag envTag) error {
+		_, source, err := lookupValue(tag, opts)
+		if err != nil {
+			return err
+		}
+		if source == "" {
+			source = SourceDefault
+		}
+
+		info := FieldInfo{
+			Field:    path,
+			Variable: tag.Name,
+			Type:     field.Type.String(),
+			Default:  tag.DefaultValue,
+			Value:    fmt.Sprint(value.Interface()),
+			Source:   source,
+			Required: tag.Required,
+			Secret:   tag.Secret,
+		}
+		if tag.Secret {
+			info.Default = maskSecret(info.Default)
+			info.Value = maskSecret(info.Value)
+		}
+		description = append(description, info)
+		return nil
+	})
+	return description, err
+}
+
+func maskSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+	return secretMask
+}
+
+// Table выводит описание выровненной текстовой таблицей
+func (d Description) Table() string {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VARIABLE\tTYPE\tVALUE\tDEFAULT\tSOURCE\tREQUIRED")
+	for _, f := range d {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%t\n", f.Variable, f.Type, f.Value, f.Default, f.Source, f.Required)
+	}
+	w.Flush()
+	return sb.String()
+}
+
+// JSON выводит описание в JSON с отступами
+func (d Description) JSON() ([]byte, error) {
+	return json.MarshalIndent(d, "", "  ")
+}
+
+// Markdown выводит справочную таблицу конфигурации без текущих значений
+func (d Description) Markdown() string {
+	var sb strings.Builder
+	sb.WriteString("| Variable | Type | Default | Required | Secret |\n")
+	sb.WriteString("|---|---|---|---|---|\n")
+	for _, f := range d {
+		fmt.Fprintf(&sb, "| `%s` | `%s` | %s | %s | %s |\n",
+			f.Variable, f.Type, markdownCode(f.Default), yesNo(f.Required), yesNo(f.Secret))
+	}
+	return sb.String()
+}
+
+// DotEnvExample выводит файл .env.example со значениями по умолчанию и пустыми секретами
+func (d Description) DotEnvExample() string {
+	var sb strings.Builder
+	for _, f := range d {
+		var notes []string
+		notes = append(notes, f.Type)
+		if f.Required {
+			notes = append(notes, "required")
+		}
+		if f.Secret {
+			notes = append(notes, "secret")
+		}
+		fmt.Fprintf(&sb, "# %s (%s)\n", f.Field, strings.Join(notes, ", "))
+
+		value := f.Default
+		if f.Secret {
+			value = ""
+		}
+		fmt.Fprintf(&sb, "%s=%s\n\n", f.Variable, value)
+	}
+	return sb.String()
+}
+
+// Render записывает описание в заданном формате: table, json, markdown или env
+func (d Description) Render(w io.Writer, format string) error {
+	switch format {
+	case "", "table":
+		_, err := io.WriteString(w, d.Table())
+		return err
+	case "json":
+		data, err := d.JSON()
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case "markdown", "md":
+		_, err := io.WriteString(w, d.Markdown())
+		return err
+	case "env", "dotenv":
+		_, err := io.WriteString(w, d.DotEnvExample())
+		return err
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// RunDescribeCommand обрабатывает подкоманду CLI "config [table|json|markdown|env]", main вызывает её
+// с os.Args[1:] до запуска сервиса. Возвращает false, если args не начинаются с "config"
+func RunDescribeCommand(args []string, config any, opts LoadOptions, w io.Writer) (bool, error) {
+	if len(args) == 0 || args[0] != "config" {
+		return false, nil
+	}
+
+	format := ""
+	if len(args) > 1 {
+		format = args[1]
+	}
+
+	description, err := DescribeWithOptions(config, opts)
+	if err != nil {
+		return true, err
+	}
+	return true, description.Render(w, format)
+}
+
+func markdownCode(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "`" + value + "`"
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
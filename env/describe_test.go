@@ -0,0 +1,93 @@
+package env
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type describeConfig struct {
+	Port     int    `env:"TEST_PORT; default:8080"`
+	Password string `env:"TEST_DB_PASSWORD; secret; default:changeme"`
+	Name     string `env:"TEST_NAME"`
+	Token    string `env:"TEST_TOKEN; required"`
+}
+
+func TestDescribe(t *testing.T) {
+	t.Setenv("TEST_PORT", "")
+	t.Setenv("TEST_DB_PASSWORD", "hunter2")
+	t.Setenv("TEST_DB_PASSWORD_FILE", "")
+	t.Setenv("TEST_NAME", "")
+	t.Setenv("TEST_NAME_FILE", "")
+	t.Setenv("TEST_TOKEN", "abc")
+
+	var config describeConfig
+	if err := LoadEnv(&config); err != nil {
+		t.Fatal(err)
+	}
+	description, err := Describe(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(description) != 4 {
+		t.Fatalf("got %d fields, want 4", len(description))
+	}
+
+	port, password, name, token := description[0], description[1], description[2], description[3]
+	if port.Variable != "TEST_PORT" || port.Type != "int" || port.Value != "8080" || port.Source != SourceDefault || port.Required {
+		t.Fatalf("unexpected port field %+v", port)
+	}
+	if password.Value != secretMask || password.Default != secretMask || password.Source != SourceEnv || !password.Secret {
+		t.Fatalf("secret is not masked: %+v", password)
+	}
+	if name.Required {
+		t.Fatalf("field without the required option is required: %+v", name)
+	}
+	if !token.Required {
+		t.Fatalf("field with the required option is not required: %+v", token)
+	}
+
+	data, err := description.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("hunter2")) || bytes.Contains(data, []byte("changeme")) {
+		t.Fatalf("JSON leaks the secret: %s", data)
+	}
+	var decoded []FieldInfo
+	if err := json.Unmarshal(data, &decoded); err != nil || len(decoded) != 4 {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	example := description.DotEnvExample()
+	if !strings.Contains(example, "TEST_PORT=8080\n") || !strings.Contains(example, "TEST_DB_PASSWORD=\n") {
+		t.Fatalf("unexpected .env.example:\n%s", example)
+	}
+	if markdown := description.Markdown(); !strings.Contains(markdown, "| `TEST_PORT` | `int` | `8080` | no | no |") {
+		t.Fatalf("unexpected markdown:\n%s", markdown)
+	}
+}
+
+func TestRunDescribeCommand(t *testing.T) {
+	config := describeConfig{Port: 1}
+
+	var out bytes.Buffer
+	handled, err := RunDescribeCommand([]string{"serve"}, &config, LoadOptions{}, &out)
+	if handled || err != nil || out.Len() != 0 {
+		t.Fatalf("got %v, %v, %q for another command", handled, err, out.String())
+	}
+
+	handled, err = RunDescribeCommand([]string{"config", "table"}, &config, LoadOptions{}, &out)
+	if !handled || err != nil {
+		t.Fatalf("got %v, %v", handled, err)
+	}
+	if !strings.HasPrefix(out.String(), "VARIABLE") || !strings.Contains(out.String(), "TEST_PORT") {
+		t.Fatalf("unexpected table:\n%s", out.String())
+	}
+
+	handled, err = RunDescribeCommand([]string{"config", "yaml"}, &config, LoadOptions{}, &out)
+	if !handled || err == nil {
+		t.Fatalf("got %v, %v for an unknown format", handled, err)
+	}
+}
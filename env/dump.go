@@ -0,0 +1,59 @@
+package env
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Dump возвращает загруженные значения по именам переменных. Поля с опцией secret исключаются,
+// поэтому результат можно выводить в лог
+func Dump(config any) map[string]string {
+	result := make(map[string]string)
+	walkFields(reflect.Indirect(reflect.ValueOf(config)), "", "", func(path string, field reflect.StructField, value reflect.Value, tag envTag) error {
+		if !tag.Secret {
+			result[tag.Name] = fmt.Sprint(value.Interface())
+		}
+		return nil
+	})
+	return result
+}
+
+// walkFields вызывает fn для каждого экспортируемого поля с тегом env, обходя вложенные структуры.
+// К именам переменных вложенных полей добавляется envPrefix родительских полей
+func walkFields(v reflect.Value, path, prefix string, fn func(path string, field reflect.StructField, value reflect.Value, tag envTag) error) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag, err := parseEnvTag(field.Tag.Get("env"))
+		if err != nil {
+			return fmt.Errorf("field %s%s: %w", path, field.Name, err)
+		}
+
+		if field.Type.Kind() == reflect.Struct && !isLeafType(field.Type) {
+			if tag.Name != "" {
+				return fmt.Errorf("field %s%s: struct fields accept only the envPrefix option", path, field.Name)
+			}
+			if err := walkFields(v.Field(i), path+field.Name+".", prefix+tag.Prefix, fn); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if tag.Name == "" {
+			continue
+		}
+		if tag.Prefix != "" {
+			return fmt.Errorf("field %s%s: option envPrefix is allowed only on struct fields", path, field.Name)
+		}
+		tag.Name = prefix + tag.Name
+
+		if err := fn(path+field.Name, field, v.Field(i), tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
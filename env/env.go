@@ -6,7 +6,7 @@ import (
 	"time"
 )
 
-// GetString returns environment variable value as string with fallback
+// GetString возвращает значение переменной окружения строкой или fallback
 func GetString(key string, fallback string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -14,7 +14,7 @@ func GetString(key string, fallback string) string {
 	return fallback
 }
 
-// GetInt returns environment variable value as integer with fallback
+// GetInt возвращает значение переменной окружения целым числом или fallback
 func GetInt(key string, fallback int) int {
 	if value := os.Getenv(key); value != "" {
 		if intValue, err := strconv.Atoi(value); err == nil {
@@ -24,7 +24,7 @@ func GetInt(key string, fallback int) int {
 	return fallback
 }
 
-// GetBool returns environment variable value as boolean with fallback
+// GetBool возвращает значение переменной окружения логическим значением или fallback
 func GetBool(key string, fallback bool) bool {
 	if value := os.Getenv(key); value != "" {
 		if boolValue, err := strconv.ParseBool(value); err == nil {
@@ -34,7 +34,7 @@ func GetBool(key string, fallback bool) bool {
 	return fallback
 }
 
-// GetDuration returns environment variable value as duration with fallback
+// GetDuration возвращает значение переменной окружения длительностью или fallback
 func GetDuration(key string, fallback time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
@@ -1,70 +1,204 @@
 package env
 
 import (
+	"encoding"
+	"fmt"
 	"os"
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 )
 
+// LoadOptions задаёт дополнительные источники значений для LoadEnvWithOptions
+type LoadOptions struct {
+	// Sources перечисляет источники значений в порядке приоритета. По умолчанию используется только окружение
+	Sources []Source
+	// SecretProvider используется для полей с опцией secret, если значение не найдено в окружении
+	SecretProvider SecretProvider
+}
+
 // LoadEnv загружает значения из переменных окружения в структуру на основе тегов env
 func LoadEnv(config any) error {
-	return loadEnvValue(reflect.ValueOf(config).Elem())
+	return LoadEnvWithOptions(config, LoadOptions{})
 }
 
-func loadEnvValue(v reflect.Value) error {
-	t := v.Type()
+// LoadEnvWithOptions загружает значения в структуру, используя дополнительные источники из opts
+func LoadEnvWithOptions(config any, opts LoadOptions) error {
+	return loadEnvValue(reflect.ValueOf(config).Elem(), opts)
+}
 
-	// Проходим по всем полям структуры
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		value := v.Field(i)
+// Источники, из которых может быть получено значение поля
+const (
+	SourceEnv            = "env"
+	SourceFile           = "file"
+	SourceSecretProvider = "secret_provider"
+	SourceDefault        = "default"
+)
 
-		// Если поле является структурой, рекурсивно обрабатываем её
-		if field.Type.Kind() == reflect.Struct {
-			if err := loadEnvValue(value); err != nil {
-				return err
-			}
-			continue
+// lookupValue ищет значение переменной: сначала в источниках, затем в файле из NAME_FILE,
+// затем (для секретов) в провайдере секретов. Возвращает источник или пустую строку, если значение не найдено
+func lookupValue(tag envTag, opts LoadOptions) (string, string, error) {
+	sources := opts.Sources
+	if len(sources) == 0 {
+		sources = []Source{EnvSource{}}
+	}
+
+	for _, src := range sources {
+		if value, ok := src.Lookup(tag.Name); ok && value != "" {
+			return value, src.Name(), nil
+		}
+	}
+
+	if path := lookupSources(sources, tag.Name+"_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", "", fmt.Errorf("read %s_FILE: %w", tag.Name, err)
 		}
+		return strings.TrimSpace(string(data)), SourceFile, nil
+	}
 
-		// Получаем тег env
-		envTag := field.Tag.Get("env")
-		if envTag == "" {
-			continue
+	if tag.Secret && opts.SecretProvider != nil {
+		value, ok, err := opts.SecretProvider.GetSecret(tag.Name)
+		if err != nil {
+			return "", "", fmt.Errorf("get secret %s: %w", tag.Name, err)
+		}
+		if ok {
+			return value, SourceSecretProvider, nil
 		}
+	}
 
-		// Разбираем тег на имя переменной и значение по умолчанию
-		parts := strings.Split(envTag, ";")
-		envVar := strings.TrimSpace(parts[0])
-		defaultValue := ""
-		if len(parts) > 1 {
-			defaultPart := strings.TrimSpace(parts[1])
-			if strings.HasPrefix(defaultPart, "default:") {
-				defaultValue = strings.TrimSpace(strings.TrimPrefix(defaultPart, "default:"))
+	return "", "", nil
+}
+
+func lookupSources(sources []Source, key string) string {
+	for _, src := range sources {
+		if value, ok := src.Lookup(key); ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func loadEnvValue(v reflect.Value, opts LoadOptions) error {
+	return walkFields(v, "", "", func(path string, field reflect.StructField, value reflect.Value, tag envTag) error {
+		// Получаем значение из окружения, файла или провайдера секретов, иначе используем значение по умолчанию
+		envValue, source, err := lookupValue(tag, opts)
+		if err != nil {
+			return err
+		}
+		if source == "" {
+			if tag.Required {
+				return fmt.Errorf("required variable %s for field %s is not set", tag.Name, path)
 			}
+			envValue = tag.DefaultValue
 		}
 
-		// Получаем значение из переменной окружения или используем значение по умолчанию
-		envValue := os.Getenv(envVar)
 		if envValue == "" {
-			envValue = defaultValue
+			return nil
 		}
 
 		// Устанавливаем значение в поле структуры в зависимости от типа
-		switch value.Kind() {
-		case reflect.String:
-			value.SetString(envValue)
-		case reflect.Int, reflect.Int64:
-			if intVal, err := strconv.Atoi(envValue); err == nil {
-				value.SetInt(int64(intVal))
+		if err := setValue(value, envValue, tag); err != nil {
+			return fmt.Errorf("invalid value of %s for field %s: %w", tag.Name, path, err)
+		}
+		return nil
+	})
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// isLeafType сообщает, что значение типа задаётся одной переменной, а не вложенными полями
+func isLeafType(t reflect.Type) bool {
+	return t == timeType || reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+func setValue(value reflect.Value, raw string, tag envTag) error {
+	if value.CanAddr() {
+		if u, ok := value.Addr().Interface().(encoding.TextUnmarshaler); ok && value.Type() != timeType {
+			return u.UnmarshalText([]byte(raw))
+		}
+	}
+
+	switch value.Type() {
+	case durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(d))
+		return nil
+	case timeType:
+		layout := tag.Layout
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		t, err := time.Parse(layout, raw)
+		if err != nil {
+			return err
+		}
+		value.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(raw, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(raw, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetFloat(f)
+	case reflect.Slice:
+		sep := tag.Separator
+		if sep == "" {
+			sep = ","
+		}
+		parts := strings.Split(raw, sep)
+		slice := reflect.MakeSlice(value.Type(), 0, len(parts))
+		for _, part := range parts {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
 			}
-		case reflect.Bool:
-			if boolVal, err := strconv.ParseBool(envValue); err == nil {
-				value.SetBool(boolVal)
+			elem := reflect.New(value.Type().Elem()).Elem()
+			if err := setValue(elem, part, tag); err != nil {
+				return err
 			}
+			slice = reflect.Append(slice, elem)
 		}
+		value.Set(slice)
+	case reflect.Pointer:
+		elem := reflect.New(value.Type().Elem())
+		if err := setValue(elem.Elem(), raw, tag); err != nil {
+			return err
+		}
+		value.Set(elem)
+	default:
+		return fmt.Errorf("unsupported type %s", value.Type())
 	}
-
 	return nil
 }
@@ -0,0 +1,86 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type mapSecretProvider map[string]string
+
+func (p mapSecretProvider) GetSecret(key string) (string, bool, error) {
+	value, ok := p[key]
+	return value, ok, nil
+}
+
+type secretsConfig struct {
+	Host     string `env:"TEST_HOST; default:localhost"`
+	Password string `env:"TEST_PASSWORD; secret"`
+	Token    string `env:"TEST_TOKEN"`
+}
+
+func TestLoadEnvFileVariables(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(path, []byte("  from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_HOST", "")
+	t.Setenv("TEST_PASSWORD", "")
+	t.Setenv("TEST_PASSWORD_FILE", path)
+
+	var config secretsConfig
+	if err := LoadEnv(&config); err != nil {
+		t.Fatal(err)
+	}
+	if config.Password != "from-file" {
+		t.Fatalf("Password = %q, want the trimmed file content", config.Password)
+	}
+	if config.Host != "localhost" {
+		t.Fatalf("Host = %q, want the default", config.Host)
+	}
+
+	// Сама переменная важнее файла
+	t.Setenv("TEST_PASSWORD", "from-env")
+	if err := LoadEnv(&config); err != nil {
+		t.Fatal(err)
+	}
+	if config.Password != "from-env" {
+		t.Fatalf("Password = %q, want the variable value", config.Password)
+	}
+
+	t.Setenv("TEST_PASSWORD", "")
+	t.Setenv("TEST_PASSWORD_FILE", filepath.Join(t.TempDir(), "missing"))
+	if err := LoadEnv(&config); err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+}
+
+func TestLoadEnvSecretProvider(t *testing.T) {
+	t.Setenv("TEST_PASSWORD", "")
+	t.Setenv("TEST_PASSWORD_FILE", "")
+	t.Setenv("TEST_TOKEN", "")
+	provider := mapSecretProvider{"TEST_PASSWORD": "from-provider", "TEST_TOKEN": "not-secret"}
+
+	var config secretsConfig
+	if err := LoadEnvWithOptions(&config, LoadOptions{SecretProvider: provider}); err != nil {
+		t.Fatal(err)
+	}
+	if config.Password != "from-provider" {
+		t.Fatalf("Password = %q, want the provider value", config.Password)
+	}
+	// Провайдер используется только для полей с опцией secret
+	if config.Token != "" {
+		t.Fatalf("Token = %q, want it empty", config.Token)
+	}
+}
+
+func TestDumpExcludesSecrets(t *testing.T) {
+	config := secretsConfig{Host: "db", Password: "hunter2", Token: "abc"}
+	dump := Dump(&config)
+	if _, ok := dump["TEST_PASSWORD"]; ok {
+		t.Fatal("secret field is dumped")
+	}
+	if dump["TEST_HOST"] != "db" || dump["TEST_TOKEN"] != "abc" {
+		t.Fatalf("unexpected dump %v", dump)
+	}
+}
@@ -0,0 +1,120 @@
+package env
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+// SecretProvider возвращает значения полей с опцией secret
+type SecretProvider interface {
+	GetSecret(key string) (string, bool, error)
+}
+
+// EncryptedFileProvider отдаёт секреты из локального dotenv-файла, зашифрованного AES-GCM
+type EncryptedFileProvider struct {
+	secrets map[string]string
+}
+
+// NewEncryptedFileProvider расшифровывает файл path ключом key (16, 24 или 32 байта)
+func NewEncryptedFileProvider(path string, key []byte) (*EncryptedFileProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read secrets file: %w", err)
+	}
+
+	plain, err := DecryptSecrets(data, key)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets, err := godotenv.Unmarshal(string(plain))
+	if err != nil {
+		return nil, fmt.Errorf("parse secrets file: %w", err)
+	}
+
+	return &EncryptedFileProvider{secrets: secrets}, nil
+}
+
+// NewEncryptedFileProviderFromEnv создаёт провайдер по ENV_SECRETS_FILE и ключу в base64 из ENV_SECRETS_KEY.
+// Возвращает nil, если ENV_SECRETS_FILE не задан
+func NewEncryptedFileProviderFromEnv() (*EncryptedFileProvider, error) {
+	path := GetString("ENV_SECRETS_FILE", "")
+	if path == "" {
+		return nil, nil
+	}
+
+	encodedKey, source, err := lookupValue(envTag{Name: "ENV_SECRETS_KEY"}, LoadOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if source == "" {
+		return nil, errors.New("ENV_SECRETS_KEY is required when ENV_SECRETS_FILE is set")
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return nil, fmt.Errorf("decode ENV_SECRETS_KEY: %w", err)
+	}
+
+	return NewEncryptedFileProvider(path, key)
+}
+
+func (p *EncryptedFileProvider) GetSecret(key string) (string, bool, error) {
+	value, ok := p.secrets[key]
+	return value, ok, nil
+}
+
+// EncryptSecrets шифрует содержимое dotenv-файла в формат, который читает EncryptedFileProvider
+func EncryptSecrets(plain []byte, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+
+	sealed := gcm.Seal(nonce, nonce, plain, nil)
+	return []byte(base64.StdEncoding.EncodeToString(sealed)), nil
+}
+
+// DecryptSecrets выполняет обратное преобразование к EncryptSecrets
+func DecryptSecrets(data []byte, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("decode secrets file: %w", err)
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("secrets file is too short")
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt secrets file: %w", err)
+	}
+	return plain, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,77 @@
+package env
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncryptDecryptSecrets(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 32)
+	plain := []byte("DB_PASSWORD=secret\nAPI_KEY='a;b'\n")
+
+	sealed, err := EncryptSecrets(plain, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(sealed, []byte("secret")) {
+		t.Fatal("encrypted file contains the plain value")
+	}
+
+	got, err := DecryptSecrets(sealed, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, plain) {
+		t.Fatalf("got %q, want %q", got, plain)
+	}
+
+	if _, err := DecryptSecrets(sealed, bytes.Repeat([]byte{2}, 32)); err == nil {
+		t.Fatal("expected an error for a wrong key")
+	}
+	if _, err := DecryptSecrets([]byte("AAAA"), key); err == nil {
+		t.Fatal("expected an error for a truncated file")
+	}
+	if _, err := EncryptSecrets(plain, []byte("short")); err == nil {
+		t.Fatal("expected an error for an invalid key size")
+	}
+}
+
+func TestEncryptedFileProviderFromEnv(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 16)
+	sealed, err := EncryptSecrets([]byte("DB_PASSWORD=from-file\n"), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "secrets.enc")
+	if err := os.WriteFile(path, sealed, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("ENV_SECRETS_FILE", "")
+	provider, err := NewEncryptedFileProviderFromEnv()
+	if err != nil || provider != nil {
+		t.Fatalf("got %v, %v without ENV_SECRETS_FILE, want nil, nil", provider, err)
+	}
+
+	t.Setenv("ENV_SECRETS_FILE", path)
+	t.Setenv("ENV_SECRETS_KEY", "")
+	if _, err := NewEncryptedFileProviderFromEnv(); err == nil {
+		t.Fatal("expected an error without ENV_SECRETS_KEY")
+	}
+
+	t.Setenv("ENV_SECRETS_KEY", base64.StdEncoding.EncodeToString(key))
+	provider, err = NewEncryptedFileProviderFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, ok, err := provider.GetSecret("DB_PASSWORD")
+	if err != nil || !ok || value != "from-file" {
+		t.Fatalf("got %q, %v, %v", value, ok, err)
+	}
+	if _, ok, _ := provider.GetSecret("MISSING"); ok {
+		t.Fatal("unexpected value for a missing key")
+	}
+}
@@ -0,0 +1,81 @@
+package env
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+// Source отдаёт исходные значения переменных для LoadEnvWithOptions
+type Source interface {
+	Name() string
+	Lookup(key string) (string, bool)
+}
+
+// EnvSource читает переменные из окружения процесса
+type EnvSource struct{}
+
+func (EnvSource) Name() string {
+	return SourceEnv
+}
+
+func (EnvSource) Lookup(key string) (string, bool) {
+	return os.LookupEnv(key)
+}
+
+// FileSource хранит переменные, прочитанные из dotenv-файла
+type FileSource struct {
+	path   string
+	values map[string]string
+}
+
+// NewFileSource читает dotenv-файл path
+func NewFileSource(path string) (*FileSource, error) {
+	values, err := godotenv.Read(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	return &FileSource{path: path, values: values}, nil
+}
+
+func (s *FileSource) Name() string {
+	return "dotenv:" + s.path
+}
+
+func (s *FileSource) Lookup(key string) (string, bool) {
+	value, ok := s.values[key]
+	return value, ok
+}
+
+// MapSource отдаёт переменные из заданной карты
+type MapSource struct {
+	name   string
+	values map[string]string
+}
+
+func NewMapSource(name string, values map[string]string) *MapSource {
+	return &MapSource{name: name, values: values}
+}
+
+// EnvSnapshot сохраняет текущее окружение процесса. Снимайте его до загрузки .env-файлов,
+// чтобы переменные реального окружения были важнее файлов
+func EnvSnapshot() *MapSource {
+	values := make(map[string]string)
+	for _, kv := range os.Environ() {
+		if key, value, ok := strings.Cut(kv, "="); ok {
+			values[key] = value
+		}
+	}
+	return NewMapSource(SourceEnv, values)
+}
+
+func (s *MapSource) Name() string {
+	return s.name
+}
+
+func (s *MapSource) Lookup(key string) (string, bool) {
+	value, ok := s.values[key]
+	return value, ok
+}
@@ -0,0 +1,196 @@
+package env
+
+import (
+	"fmt"
+	"strings"
+)
+
+// envTag описывает разобранный тег env.
+//
+// Грамматика тега: `env:"NAME; option; option:value"`. Имя переменной и опции могут следовать
+// в любом порядке, имя — это сегмент без значения, не совпадающий с опцией. Значение опции
+// может быть заключено в одинарные или двойные кавычки, внутри значения обратная косая черта
+// экранирует следующий символ, например
+// `default:'host=db;port=5432'` или `default:a\\;b` (в литерале struct tag черта удваивается).
+//
+// Поддерживаемые опции: default, required, secret, reload, sep, layout, envPrefix.
+// Опция required запрещает значение по умолчанию: загрузка завершается ошибкой, если переменная не задана
+type envTag struct {
+	Name         string
+	DefaultValue string
+	HasDefault   bool
+	Secret       bool
+	Required     bool
+	Reload       bool
+	Separator    string
+	Layout       string
+	Prefix       string
+}
+
+func parseEnvTag(tag string) (envTag, error) {
+	segments, err := splitTag(tag)
+	if err != nil {
+		return envTag{}, err
+	}
+
+	var parsed envTag
+	seen := make(map[string]bool)
+	for i, segment := range segments {
+		key, value, hasValue := segment.key, segment.value, segment.hasValue
+
+		if key == "" {
+			// Пустой тег или пустое имя перед опциями, например `env:"; envPrefix:DB_"`
+			if i == 0 && !hasValue {
+				continue
+			}
+			return envTag{}, fmt.Errorf("empty option in tag %q", tag)
+		}
+
+		// Сегмент без значения, не совпадающий с опцией-флагом, считается именем переменной
+		if !hasValue && !isFlagOption(key) && !isValueOption(key) {
+			if parsed.Name != "" {
+				return envTag{}, fmt.Errorf("unknown option %q in tag %q", key, tag)
+			}
+			parsed.Name = key
+			continue
+		}
+		if seen[key] {
+			return envTag{}, fmt.Errorf("duplicate option %q in tag %q", key, tag)
+		}
+		seen[key] = true
+
+		if isFlagOption(key) {
+			if hasValue {
+				return envTag{}, fmt.Errorf("option %q does not take a value in tag %q", key, tag)
+			}
+		} else if !hasValue {
+			return envTag{}, fmt.Errorf("option %q requires a value in tag %q", key, tag)
+		}
+
+		switch key {
+		case "default":
+			parsed.DefaultValue = value
+			parsed.HasDefault = true
+		case "required":
+			parsed.Required = true
+		case "secret":
+			parsed.Secret = true
+		case "reload":
+			parsed.Reload = true
+		case "sep":
+			parsed.Separator = value
+		case "layout":
+			parsed.Layout = value
+		case "envPrefix":
+			parsed.Prefix = value
+		default:
+			return envTag{}, fmt.Errorf("unknown option %q in tag %q", key, tag)
+		}
+	}
+
+	if parsed.Name == "" && (parsed.HasDefault || parsed.Required || parsed.Secret || parsed.Reload) {
+		return envTag{}, fmt.Errorf("variable name is missing in tag %q", tag)
+	}
+
+	if parsed.Required && parsed.HasDefault {
+		return envTag{}, fmt.Errorf("options required and default are mutually exclusive in tag %q", tag)
+	}
+
+	return parsed, nil
+}
+
+func isFlagOption(key string) bool {
+	switch key {
+	case "required", "secret", "reload":
+		return true
+	}
+	return false
+}
+
+func isValueOption(key string) bool {
+	switch key {
+	case "default", "sep", "layout", "envPrefix":
+		return true
+	}
+	return false
+}
+
+type tagSegment struct {
+	key      string
+	value    string
+	hasValue bool
+}
+
+// splitTag разбивает тег на сегменты по ";" с учётом кавычек и экранирования
+func splitTag(tag string) ([]tagSegment, error) {
+	var (
+		segments []tagSegment
+		current  tagSegment
+		buf      strings.Builder
+		quote    rune
+		quoted   bool
+		escaped  bool
+	)
+
+	flush := func() {
+		text := buf.String()
+		if !quoted {
+			text = strings.TrimSpace(text)
+		}
+		if current.hasValue {
+			current.value = text
+		} else {
+			current.key = text
+		}
+		buf.Reset()
+	}
+
+	for _, r := range tag {
+		switch {
+		case escaped:
+			buf.WriteRune(r)
+			escaped = false
+		case quote != 0:
+			switch r {
+			case '\\':
+				escaped = true
+			case quote:
+				quote = 0
+			default:
+				buf.WriteRune(r)
+			}
+		case r == ';':
+			flush()
+			segments = append(segments, current)
+			current = tagSegment{}
+			quoted = false
+		case quoted:
+			// после закрывающей кавычки допускаются только пробелы
+			if r != ' ' && r != '\t' {
+				return nil, fmt.Errorf("unexpected %q after quoted value in tag %q", r, tag)
+			}
+		case r == '\\':
+			escaped = true
+		case (r == '\'' || r == '"') && current.hasValue && strings.TrimSpace(buf.String()) == "":
+			buf.Reset()
+			quote = r
+			quoted = true
+		case r == ':' && !current.hasValue:
+			flush()
+			current.hasValue = true
+		default:
+			buf.WriteRune(r)
+		}
+	}
+
+	if escaped {
+		return nil, fmt.Errorf("unterminated escape in tag %q", tag)
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in tag %q", tag)
+	}
+
+	flush()
+	segments = append(segments, current)
+	return segments, nil
+}
@@ -0,0 +1,129 @@
+package env
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseEnvTag(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want envTag
+	}{
+		{tag: "PORT", want: envTag{Name: "PORT"}},
+		{tag: "PORT; default:8080", want: envTag{Name: "PORT", DefaultValue: "8080", HasDefault: true}},
+		{tag: "default:8080; PORT", want: envTag{Name: "PORT", DefaultValue: "8080", HasDefault: true}},
+		{tag: "PASSWORD; secret; default:postgres", want: envTag{Name: "PASSWORD", DefaultValue: "postgres", HasDefault: true, Secret: true}},
+		{tag: "PREFIX; default:", want: envTag{Name: "PREFIX", HasDefault: true}},
+		{tag: `DSN; default:'host=db;port=5432'`, want: envTag{Name: "DSN", DefaultValue: "host=db;port=5432", HasDefault: true}},
+		{tag: `DSN; default:"a \"quoted\" value"`, want: envTag{Name: "DSN", DefaultValue: `a "quoted" value`, HasDefault: true}},
+		{tag: `LIST; default:a\;b; sep:|`, want: envTag{Name: "LIST", DefaultValue: "a;b", HasDefault: true, Separator: "|"}},
+		{tag: "TOKEN; required; secret", want: envTag{Name: "TOKEN", Required: true, Secret: true}},
+		{tag: "AT; layout:2006-01-02; reload", want: envTag{Name: "AT", Layout: "2006-01-02", Reload: true}},
+		{tag: "; envPrefix:DB_", want: envTag{Prefix: "DB_"}},
+		{tag: "", want: envTag{}},
+	}
+	for _, tt := range tests {
+		got, err := parseEnvTag(tt.tag)
+		if err != nil {
+			t.Errorf("parseEnvTag(%q): %v", tt.tag, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseEnvTag(%q) = %+v, want %+v", tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestParseEnvTagErrors(t *testing.T) {
+	tags := []string{
+		"PORT; unknown:1",
+		"PORT; OTHER",
+		"PORT; default:1; default:2",
+		"PORT; secret:yes",
+		"PORT; sep",
+		"PORT; required:yes",
+		"PORT; required; default:1",
+		"PORT; required; default:",
+		"required",
+		"default:1",
+		"PORT; default:'unterminated",
+		`PORT; default:'a' b`,
+		`PORT; default:a\`,
+		"PORT;; secret",
+	}
+	for _, tag := range tags {
+		if _, err := parseEnvTag(tag); err == nil {
+			t.Errorf("parseEnvTag(%q) succeeded, want an error", tag)
+		}
+	}
+}
+
+type grammarConfig struct {
+	DSN      string        `env:"TEST_GRAMMAR_DSN; default:'host=db;port=5432'"`
+	Origins  []string      `env:"TEST_GRAMMAR_ORIGINS; default:'https://a.example;https://b.example'; sep:';'"`
+	Timeout  time.Duration `env:"default:5s; TEST_GRAMMAR_TIMEOUT"`
+	Date     time.Time     `env:"TEST_GRAMMAR_DATE; layout:2006-01-02; default:2024-03-01"`
+	Database struct {
+		Name string `env:"NAME; default:app"`
+	} `env:"envPrefix:TEST_GRAMMAR_DB_"`
+}
+
+func TestLoadEnvGrammar(t *testing.T) {
+	for _, name := range []string{"TEST_GRAMMAR_DSN", "TEST_GRAMMAR_ORIGINS", "TEST_GRAMMAR_TIMEOUT", "TEST_GRAMMAR_DATE"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("TEST_GRAMMAR_DB_NAME", "orders")
+
+	var config grammarConfig
+	if err := LoadEnv(&config); err != nil {
+		t.Fatal(err)
+	}
+	if config.DSN != "host=db;port=5432" {
+		t.Errorf("DSN = %q", config.DSN)
+	}
+	if !reflect.DeepEqual(config.Origins, []string{"https://a.example", "https://b.example"}) {
+		t.Errorf("Origins = %q", config.Origins)
+	}
+	if config.Timeout != 5*time.Second {
+		t.Errorf("Timeout = %v", config.Timeout)
+	}
+	if !config.Date.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Date = %v", config.Date)
+	}
+	if config.Database.Name != "orders" {
+		t.Errorf("Database.Name = %q, want the prefixed variable", config.Database.Name)
+	}
+}
+
+func TestLoadEnvInvalidTag(t *testing.T) {
+	var config struct {
+		Port int `env:"TEST_PORT; defualt:1"`
+	}
+	if err := LoadEnv(&config); err == nil {
+		t.Fatal("expected an error for an unknown option")
+	}
+}
+
+func TestLoadEnvRequired(t *testing.T) {
+	var config struct {
+		Token string `env:"TEST_REQUIRED_TOKEN; required"`
+	}
+
+	t.Setenv("TEST_REQUIRED_TOKEN", "")
+	t.Setenv("TEST_REQUIRED_TOKEN_FILE", "")
+	err := LoadEnv(&config)
+	if err == nil || !strings.Contains(err.Error(), "required variable TEST_REQUIRED_TOKEN for field Token is not set") {
+		t.Fatalf("LoadEnv error = %v, want the unset required variable", err)
+	}
+
+	t.Setenv("TEST_REQUIRED_TOKEN", "abc")
+	if err := LoadEnv(&config); err != nil {
+		t.Fatal(err)
+	}
+	if config.Token != "abc" {
+		t.Errorf("Token = %q", config.Token)
+	}
+}
@@ -0,0 +1,242 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Validator реализуют конфиги, которые проверяют себя после загрузки
+type Validator interface {
+	Validate() error
+}
+
+type WatcherOptions struct {
+	// Files — dotenv-файлы, которые перечитываются при каждой перезагрузке. Они важнее окружения процесса
+	// на момент загрузки, но не LoadOptions.Sources. Чтобы реальное окружение было важнее файлов,
+	// передайте в Sources EnvSnapshot, снятый до godotenv.Load
+	Files []string
+	// PollInterval задаёт, как часто проверяются изменения Files. Ноль отключает проверку
+	PollInterval time.Duration
+	// DisableSignal отключает перезагрузку по SIGHUP
+	DisableSignal bool
+	// LoadOptions используются при каждой загрузке. Files и окружение добавляются в конец Sources
+	LoadOptions LoadOptions
+	// OnError получает ошибки фоновых перезагрузок
+	OnError func(error)
+}
+
+// Watcher хранит конфиг типа T и перезагружает его по SIGHUP или при изменении файлов.
+// Меняться могут только поля с опцией reload, иначе перезагрузка отклоняется
+type Watcher[T any] struct {
+	opts        WatcherOptions
+	current     atomic.Pointer[T]
+	loadOptions atomic.Pointer[LoadOptions]
+
+	mu          sync.Mutex
+	subscribers []func(old, new *T)
+	modTimes    map[string]time.Time
+
+	// runMu защищает stop и done. Он отделён от mu, чтобы Stop не ждал перезагрузку, удерживающую mu
+	runMu sync.Mutex
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// ErrWatcherStarted возвращается из Start, если наблюдение уже запущено
+var ErrWatcherStarted = errors.New("config watcher is already started")
+
+// NewWatcher загружает начальный конфиг
+func NewWatcher[T any](opts WatcherOptions) (*Watcher[T], error) {
+	w := &Watcher[T]{
+		opts:     opts,
+		modTimes: make(map[string]time.Time),
+	}
+
+	config, loadOptions, err := w.load()
+	if err != nil {
+		return nil, err
+	}
+	w.current.Store(config)
+	w.loadOptions.Store(loadOptions)
+	w.filesChanged()
+
+	return w, nil
+}
+
+// Current возвращает действующий конфиг. Возвращённое значение нельзя изменять
+func (w *Watcher[T]) Current() *T {
+	return w.current.Load()
+}
+
+// LoadOptions возвращает опции, с которыми загружен действующий конфиг, включая файловые источники
+func (w *Watcher[T]) LoadOptions() LoadOptions {
+	return *w.loadOptions.Load()
+}
+
+// Subscribe регистрирует fn, которая вызывается после каждой успешной перезагрузки, изменившей конфиг
+func (w *Watcher[T]) Subscribe(fn func(old, new *T)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// Reload загружает, проверяет и подменяет конфиг, затем уведомляет подписчиков
+func (w *Watcher[T]) Reload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	config, loadOptions, err := w.load()
+	if err != nil {
+		return err
+	}
+
+	old := w.current.Load()
+	changed, err := reloadableChanges(old, config)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return nil
+	}
+
+	w.current.Store(config)
+	w.loadOptions.Store(loadOptions)
+	for _, fn := range w.subscribers {
+		fn(old, config)
+	}
+	return nil
+}
+
+// Start запускает фоновое наблюдение за SIGHUP и изменениями файлов.
+// Возвращает ErrWatcherStarted, если наблюдение уже запущено. Остановленное наблюдение можно запустить снова
+func (w *Watcher[T]) Start() error {
+	w.runMu.Lock()
+	defer w.runMu.Unlock()
+	if w.stop != nil {
+		return ErrWatcherStarted
+	}
+	stop, done := make(chan struct{}), make(chan struct{})
+	w.stop, w.done = stop, done
+
+	signals := make(chan os.Signal, 1)
+	if !w.opts.DisableSignal {
+		signal.Notify(signals, syscall.SIGHUP)
+	}
+
+	go func() {
+		defer close(done)
+		defer signal.Stop(signals)
+
+		var tick <-chan time.Time
+		if w.opts.PollInterval > 0 && len(w.opts.Files) > 0 {
+			ticker := time.NewTicker(w.opts.PollInterval)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-signals:
+				w.reportError(w.Reload())
+			case <-tick:
+				if w.filesChanged() {
+					w.reportError(w.Reload())
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop останавливает наблюдение, запущенное Start
+func (w *Watcher[T]) Stop() {
+	w.runMu.Lock()
+	defer w.runMu.Unlock()
+	if w.stop == nil {
+		return
+	}
+	close(w.stop)
+	<-w.done
+	w.stop, w.done = nil, nil
+}
+
+func (w *Watcher[T]) load() (*T, *LoadOptions, error) {
+	opts := w.opts.LoadOptions
+	sources := make([]Source, 0, len(opts.Sources)+len(w.opts.Files)+1)
+	sources = append(sources, opts.Sources...)
+	for _, path := range w.opts.Files {
+		source, err := NewFileSource(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		sources = append(sources, source)
+	}
+	opts.Sources = append(sources, EnvSource{})
+
+	config := new(T)
+	if err := LoadEnvWithOptions(config, opts); err != nil {
+		return nil, nil, err
+	}
+	if validator, ok := any(config).(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			return nil, nil, fmt.Errorf("validate config: %w", err)
+		}
+	}
+	return config, &opts, nil
+}
+
+// filesChanged сообщает, изменился ли какой-либо из файлов с предыдущего вызова
+func (w *Watcher[T]) filesChanged() bool {
+	changed := false
+	for _, path := range w.opts.Files {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if !info.ModTime().Equal(w.modTimes[path]) {
+			w.modTimes[path] = info.ModTime()
+			changed = true
+		}
+	}
+	return changed
+}
+
+func (w *Watcher[T]) reportError(err error) {
+	if err != nil && w.opts.OnError != nil {
+		w.opts.OnError(err)
+	}
+}
+
+// reloadableChanges сообщает, отличается ли new от old, и возвращает ошибку, если изменилось поле без опции reload
+func reloadableChanges[T any](old, new *T) (bool, error) {
+	oldValues := make(map[string]reflect.Value)
+	err := walkFields(reflect.ValueOf(old).Elem(), "", "", func(path string, field reflect.StructField, value reflect.Value, tag envTag) error {
+		oldValues[path] = value
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	changed := false
+	err = walkFields(reflect.ValueOf(new).Elem(), "", "", func(path string, field reflect.StructField, value reflect.Value, tag envTag) error {
+		if reflect.DeepEqual(oldValues[path].Interface(), value.Interface()) {
+			return nil
+		}
+		if !tag.Reload {
+			return fmt.Errorf("field %s (%s) is not reloadable", path, tag.Name)
+		}
+		changed = true
+		return nil
+	})
+	return changed, err
+}
@@ -0,0 +1,107 @@
+package env
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type watchedConfig struct {
+	Level string `env:"TEST_WATCH_LEVEL; reload; default:info"`
+	Port  int    `env:"TEST_WATCH_PORT; default:8080"`
+}
+
+func (c *watchedConfig) Validate() error {
+	if c.Level == "invalid" {
+		return errors.New("invalid level")
+	}
+	return nil
+}
+
+func writeDotEnv(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatcherReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	writeDotEnv(t, path, "TEST_WATCH_LEVEL=debug\n")
+
+	watcher, err := NewWatcher[watchedConfig](WatcherOptions{Files: []string{path}, DisableSignal: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if level := watcher.Current().Level; level != "debug" {
+		t.Fatalf("Level = %q, want debug", level)
+	}
+
+	var notified []string
+	watcher.Subscribe(func(old, new *watchedConfig) {
+		notified = append(notified, old.Level+"->"+new.Level)
+	})
+
+	writeDotEnv(t, path, "TEST_WATCH_LEVEL=warn\n")
+	if err := watcher.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if watcher.Current().Level != "warn" || len(notified) != 1 || notified[0] != "debug->warn" {
+		t.Fatalf("got level %q, notifications %v", watcher.Current().Level, notified)
+	}
+
+	// Неизменённый конфиг не уведомляет подписчиков
+	if err := watcher.Reload(); err != nil || len(notified) != 1 {
+		t.Fatalf("got %v, notifications %v", err, notified)
+	}
+
+	writeDotEnv(t, path, "TEST_WATCH_LEVEL=invalid\n")
+	if err := watcher.Reload(); err == nil {
+		t.Fatal("expected a validation error")
+	}
+	writeDotEnv(t, path, "TEST_WATCH_LEVEL=warn\nTEST_WATCH_PORT=9090\n")
+	if err := watcher.Reload(); err == nil {
+		t.Fatal("expected an error for a non-reloadable field")
+	}
+	if config := watcher.Current(); config.Level != "warn" || config.Port != 8080 {
+		t.Fatalf("rejected reloads changed the config: %+v", config)
+	}
+}
+
+func TestWatcherEnvironmentPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	writeDotEnv(t, path, "TEST_WATCH_LEVEL=debug\n")
+	environment := NewMapSource(SourceEnv, map[string]string{"TEST_WATCH_LEVEL": "error"})
+
+	watcher, err := NewWatcher[watchedConfig](WatcherOptions{
+		Files:         []string{path},
+		DisableSignal: true,
+		LoadOptions:   LoadOptions{Sources: []Source{environment}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if level := watcher.Current().Level; level != "error" {
+		t.Fatalf("Level = %q, the real environment must take precedence over .env", level)
+	}
+}
+
+func TestWatcherStartTwice(t *testing.T) {
+	watcher, err := NewWatcher[watchedConfig](WatcherOptions{DisableSignal: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := watcher.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := watcher.Start(); !errors.Is(err, ErrWatcherStarted) {
+		t.Fatalf("second Start returned %v, want ErrWatcherStarted", err)
+	}
+	watcher.Stop()
+	watcher.Stop()
+	if err := watcher.Start(); err != nil {
+		t.Fatalf("Start after Stop: %v", err)
+	}
+	watcher.Stop()
+}
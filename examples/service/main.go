@@ -1,7 +1,11 @@
 package main
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/gin-gonic/gin"
+	"github.com/nk-bm/gocore/env"
 	"github.com/nk-bm/gocore/gincore"
 	"github.com/nk-bm/gocore/gincore/response"
 	"github.com/nk-bm/gocore/gocore"
@@ -13,7 +17,21 @@ func HelloWorldHandler(c *gin.Context) {
 }
 
 func main() {
-	service, err := gocore.NewDefaultApp("example_service")
+	config, err := gocore.LoadDefaultConfig()
+	if err != nil {
+		panic(err)
+	}
+
+	// "example_service config [format]" prints the loaded configuration and exits
+	if handled, err := env.RunDescribeCommand(os.Args[1:], config.Current(), config.LoadOptions(), os.Stdout); handled {
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	service, err := gocore.NewDefaultAppWithWatcher("example_service", config)
 	if err != nil {
 		panic(err)
 	}
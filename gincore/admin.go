@@ -0,0 +1,174 @@
+package gincore
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nk-bm/gocore/env"
+	"github.com/nk-bm/gocore/gincore/ginmw"
+	"go.uber.org/zap"
+)
+
+// AdminConfig configures the internal server of operational endpoints.
+// Requests must pass every configured protection: Basic auth and the IP allow-list
+type AdminConfig struct {
+	Enabled bool   `env:"ADMIN_ENABLED; default:false"`
+	Host    string `env:"ADMIN_HOST; default:127.0.0.1"`
+	Port    int    `env:"ADMIN_PORT; default:9090"`
+
+	Username string `env:"ADMIN_USERNAME"`
+	Password string `env:"ADMIN_PASSWORD; secret"`
+	// AllowedIPs are addresses and CIDR ranges of clients
+	AllowedIPs []string `env:"ADMIN_ALLOWED_IPS"`
+
+	DisablePprof  bool `env:"ADMIN_DISABLE_PPROF; default:false"`
+	DisableExpvar bool `env:"ADMIN_DISABLE_EXPVAR; default:false"`
+}
+
+func (c AdminConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	return c.validate()
+}
+
+func (c AdminConfig) validate() error {
+	if (c.Username == "") != (c.Password == "") {
+		return fmt.Errorf("ADMIN_USERNAME and ADMIN_PASSWORD must be set together")
+	}
+	if c.Username == "" && len(c.AllowedIPs) == 0 {
+		return fmt.Errorf("admin server requires ADMIN_USERNAME and ADMIN_PASSWORD or ADMIN_ALLOWED_IPS")
+	}
+	if _, err := ginmw.ParseIPAllowList(c.AllowedIPs); err != nil {
+		return fmt.Errorf("invalid ADMIN_ALLOWED_IPS: %w", err)
+	}
+	return nil
+}
+
+// AdminServer serves pprof, expvar, metrics, health probes, the route list and build info of a Server
+// on a separate internal address. The Server must be created with Config.AdminServer set,
+// so that it does not serve the same endpoints publicly. The Server keeps serving the health probes,
+// since the kubelet sends no credentials
+type AdminServer struct {
+	config AdminConfig
+	Router *gin.Engine
+	server *Server
+	logger *zap.Logger
+
+	httpServer *http.Server
+	listener   net.Listener
+}
+
+// NewAdminServer returns an error when the config is invalid, it never serves unprotected endpoints
+func NewAdminServer(config AdminConfig, server *Server, logger *zap.Logger) (*AdminServer, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	allowed, err := ginmw.ParseIPAllowList(config.AllowedIPs)
+	if err != nil {
+		return nil, err
+	}
+
+	router := gin.New()
+	a := &AdminServer{config: config, Router: router, server: server, logger: logger}
+
+	router.Use(ginmw.RecoveryMW(logger))
+	if len(allowed) > 0 {
+		router.Use(ginmw.IPAllowListMW(allowed))
+	}
+	if config.Username != "" {
+		router.Use(ginmw.BasicAuthMW(config.Username, config.Password, "admin"))
+	}
+
+	a.registerHandlers()
+	return a, nil
+}
+
+func (a *AdminServer) registerHandlers() {
+	router := a.Router
+	if a.server.Metrics() != nil {
+		router.GET(a.server.config.Metrics.Path, gin.WrapH(a.server.metricsHandler()))
+	}
+	if !a.server.config.Options.DisableHealthCheckHandler {
+		a.server.registerHealthHandlers(router)
+	}
+	router.GET("/routes", RoutesHandler(a.server))
+	router.GET("/version", BuildInfoHandler)
+
+	if !a.config.DisablePprof {
+		router.GET("/debug/pprof/", gin.WrapF(pprof.Index))
+		router.GET("/debug/pprof/cmdline", gin.WrapF(pprof.Cmdline))
+		router.GET("/debug/pprof/profile", gin.WrapF(pprof.Profile))
+		router.GET("/debug/pprof/symbol", gin.WrapF(pprof.Symbol))
+		router.POST("/debug/pprof/symbol", gin.WrapF(pprof.Symbol))
+		router.GET("/debug/pprof/trace", gin.WrapF(pprof.Trace))
+		// Index serves the named profiles like heap and goroutine
+		router.GET("/debug/pprof/:profile", gin.WrapF(pprof.Index))
+	}
+	if !a.config.DisableExpvar {
+		router.GET("/debug/vars", gin.WrapH(expvar.Handler()))
+	}
+}
+
+// RegisterConfigHandler serves the configuration with secrets masked
+func (a *AdminServer) RegisterConfigHandler(config any, opts env.LoadOptions) {
+	a.Router.GET("/config", ConfigHandler(config, opts))
+}
+
+// RegisterLogLevelHandlers serves log level control
+func (a *AdminServer) RegisterLogLevelHandlers(levels LevelController) {
+	a.Router.GET("/log-level", LogLevelsHandler(levels))
+	a.Router.PUT("/log-level", SetLogLevelHandler(levels))
+}
+
+// Start listens on the configured host and port and serves in the background. Serving errors are logged,
+// the admin server does not stop the application
+func (a *AdminServer) Start() error {
+	if a.httpServer != nil {
+		return fmt.Errorf("admin server is already started")
+	}
+	listener, err := net.Listen("tcp", net.JoinHostPort(a.config.Host, fmt.Sprint(a.config.Port)))
+	if err != nil {
+		return fmt.Errorf("listen admin: %w", err)
+	}
+
+	// WriteTimeout is not set, so that long CPU profiles and traces are not cut off
+	timeouts := a.server.config.HTTP
+	a.listener = listener
+	a.httpServer = &http.Server{
+		Handler:           a.Router,
+		ReadHeaderTimeout: timeouts.ReadHeaderTimeout,
+		ReadTimeout:       timeouts.ReadTimeout,
+		IdleTimeout:       timeouts.IdleTimeout,
+		MaxHeaderBytes:    timeouts.MaxHeaderBytes,
+		ErrorLog:          zap.NewStdLog(a.logger),
+	}
+	go func() {
+		if err := a.httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			a.logger.Error("Admin server stopped", zap.Error(err))
+		}
+	}()
+	a.logger.Info("Admin server started", zap.String("addr", listener.Addr().String()))
+	return nil
+}
+
+// Addr returns the address the admin server listens on, nil before Start
+func (a *AdminServer) Addr() net.Addr {
+	if a.listener == nil {
+		return nil
+	}
+	return a.listener.Addr()
+}
+
+func (a *AdminServer) Shutdown(ctx context.Context) error {
+	if a.httpServer == nil {
+		return nil
+	}
+	return a.httpServer.Shutdown(ctx)
+}
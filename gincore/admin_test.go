@@ -0,0 +1,79 @@
+package gincore
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func newTestAdminServer(t *testing.T, config AdminConfig) (*Server, *AdminServer) {
+	t.Helper()
+	s := newTestServer(t, func(config *Config) {
+		config.AdminServer = true
+		config.Options.EnableMetrics = true
+	})
+	config.Enabled = true
+	a, err := NewAdminServer(config, s, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, a
+}
+
+// serveAdmin sends a request to the admin router, with Basic auth when username is set
+func serveAdmin(a *AdminServer, path, username, password string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if username != "" {
+		req.SetBasicAuth(username, password)
+	}
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAdminServerEndpoints(t *testing.T) {
+	s, a := newTestAdminServer(t, AdminConfig{Username: "ops", Password: "secret"})
+
+	// Probes send no credentials, so they are served by the main listener
+	for _, path := range []string{"/health", "/health/live", "/health/ready", "/health/startup"} {
+		assertStatus(t, serve(s, http.MethodGet, path, ""), http.StatusOK)
+	}
+	// Admin endpoints move off the main server
+	assertStatus(t, serve(s, http.MethodGet, "/version", ""), http.StatusNotFound)
+	assertStatus(t, serve(s, http.MethodGet, "/metrics", ""), http.StatusNotFound)
+
+	assertStatus(t, serveAdmin(a, "/metrics", "", ""), http.StatusUnauthorized)
+	assertStatus(t, serveAdmin(a, "/metrics", "ops", "wrong"), http.StatusUnauthorized)
+	assertStatus(t, serveAdmin(a, "/metrics", "ops", "secret"), http.StatusOK)
+	assertStatus(t, serveAdmin(a, "/version", "ops", "secret"), http.StatusOK)
+	for _, path := range []string{"/health", "/health/live", "/health/ready", "/health/startup"} {
+		assertStatus(t, serveAdmin(a, path, "", ""), http.StatusUnauthorized)
+		assertStatus(t, serveAdmin(a, path, "ops", "secret"), http.StatusOK)
+	}
+}
+
+func TestAdminServerIPAllowList(t *testing.T) {
+	// httptest requests come from 192.0.2.1
+	_, a := newTestAdminServer(t, AdminConfig{AllowedIPs: []string{"10.0.0.0/8"}})
+	assertStatus(t, serveAdmin(a, "/version", "", ""), http.StatusForbidden)
+
+	_, a = newTestAdminServer(t, AdminConfig{AllowedIPs: []string{"192.0.2.0/24"}})
+	assertStatus(t, serveAdmin(a, "/version", "", ""), http.StatusOK)
+}
+
+func TestAdminConfigValidate(t *testing.T) {
+	for _, config := range []AdminConfig{
+		{Enabled: true},
+		{Enabled: true, Username: "ops"},
+		{Enabled: true, AllowedIPs: []string{"not-an-ip"}},
+	} {
+		if err := config.Validate(); err == nil {
+			t.Errorf("Validate(%+v) succeeded, want an error", config)
+		}
+	}
+	if err := (AdminConfig{}).Validate(); err != nil {
+		t.Errorf("Validate of a disabled admin server = %v", err)
+	}
+}
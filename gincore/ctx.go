@@ -2,11 +2,30 @@ package gincore
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/nk-bm/gocore/gincore/ginmw"
 	"github.com/nk-bm/gocore/gincore/static"
 	"github.com/nk-bm/gocore/gotypes"
+	"go.uber.org/zap"
 )
 
+// CtxTMAInitData returns the init data set by TelegramMiniAppAuthMW
 func CtxTMAInitData(c *gin.Context) (*gotypes.TelegramMiniAppInitData, bool) {
-	initData, ok := c.Value(static.TMA_INIT_DATA).(*gotypes.TelegramMiniAppInitData)
-	return initData, ok
+	switch initData := c.Value(static.TMA_INIT_DATA).(type) {
+	case *gotypes.TelegramMiniAppInitData:
+		return initData, true
+	case gotypes.TelegramMiniAppInitData:
+		// TelegramMiniAppAuthMW stores the value, not a pointer
+		return &initData, true
+	}
+	return nil, false
+}
+
+// Logger returns the request-scoped logger with request_id, method, route and user fields
+func Logger(c *gin.Context) *zap.Logger {
+	return ginmw.CtxLogger(c)
+}
+
+// RequestID returns the id assigned to the request by the request logger middleware
+func RequestID(c *gin.Context) string {
+	return c.GetString(static.REQUEST_ID)
 }
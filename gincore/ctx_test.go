@@ -0,0 +1,28 @@
+package gincore
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nk-bm/gocore/gincore/static"
+	"github.com/nk-bm/gocore/gotypes"
+	initdata "github.com/telegram-mini-apps/init-data-golang"
+)
+
+func TestCtxTMAInitData(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/", nil)
+	if _, ok := CtxTMAInitData(c); ok {
+		t.Fatal("got init data without TelegramMiniAppAuthMW")
+	}
+
+	data := gotypes.TelegramMiniAppInitData(initdata.InitData{User: initdata.User{ID: 7}})
+	for _, value := range []any{data, &data} {
+		c.Set(static.TMA_INIT_DATA, value)
+		got, ok := CtxTMAInitData(c)
+		if !ok || got.User.ID != 7 {
+			t.Fatalf("CtxTMAInitData() = %v, %v for %T", got, ok, value)
+		}
+	}
+}
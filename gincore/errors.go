@@ -0,0 +1,114 @@
+package gincore
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nk-bm/gocore/gincore/response"
+	"go.uber.org/zap"
+)
+
+// Error is an error answered with its HTTP status by Handle and RespondError.
+// Wrap it with fmt.Errorf("...: %w", err) to add context without changing the response
+type Error struct {
+	Status  int
+	Type    string
+	Message string
+	Details []response.FieldError
+	// Err is the cause, logged for server errors but never sent to the client
+	Err error
+}
+
+// StatusCoder can be implemented by errors of other packages to choose the response status
+type StatusCoder interface {
+	StatusCode() int
+}
+
+// StatusClientClosedRequest is answered when the client cancels the request, as nginx does
+const StatusClientClosedRequest = 499
+
+// NewError returns a new Error on every call, an empty message is answered with the status text
+func NewError(status int, message string) *Error {
+	return &Error{Status: status, Message: message}
+}
+
+func BadRequestError(message string) *Error {
+	return NewError(http.StatusBadRequest, message)
+}
+
+func UnauthorizedError(message string) *Error {
+	return NewError(http.StatusUnauthorized, message)
+}
+
+func ForbiddenError(message string) *Error {
+	return NewError(http.StatusForbidden, message)
+}
+
+func NotFoundError(message string) *Error {
+	return NewError(http.StatusNotFound, message)
+}
+
+func ConflictError(message string) *Error {
+	return NewError(http.StatusConflict, message)
+}
+
+// WrapError returns an Error with the status and err as the cause. The message is err.Error()
+// for client errors and the status text for server errors
+func WrapError(status int, err error) *Error {
+	message := http.StatusText(status)
+	if status < http.StatusInternalServerError {
+		message = err.Error()
+	}
+	return &Error{Status: status, Message: message, Err: err}
+}
+
+func (e *Error) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return http.StatusText(e.Status)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// RespondError answers with the status of err. Errors without a status are answered
+// with 500 without exposing their text, server errors are logged
+func RespondError(c *gin.Context, err error) {
+	var (
+		httpErr     *Error
+		statusCoder StatusCoder
+	)
+	switch {
+	case errors.As(err, &httpErr):
+	case errors.As(err, &statusCoder):
+		httpErr = WrapError(statusCoder.StatusCode(), err)
+	case errors.As(err, new(*http.MaxBytesError)):
+		httpErr = WrapError(http.StatusRequestEntityTooLarge, err)
+	case errors.Is(err, context.DeadlineExceeded):
+		httpErr = WrapError(http.StatusGatewayTimeout, err)
+	case errors.Is(err, context.Canceled):
+		httpErr = &Error{Status: StatusClientClosedRequest, Message: "Client Closed Request", Err: err}
+	default:
+		httpErr = WrapError(http.StatusInternalServerError, err)
+	}
+
+	if httpErr.Status >= http.StatusInternalServerError {
+		Logger(c).Error("Request failed", zap.Int("status", httpErr.Status), zap.Error(err))
+	}
+
+	r := response.NewResponse().SetErrorString(httpErr.Error(), httpErr.Status)
+	if httpErr.Type != "" {
+		r.SetErrorType(httpErr.Type)
+	}
+	if len(httpErr.Details) > 0 {
+		r.SetErrorDetails(httpErr.Details)
+	}
+	r.Respond(c)
+}
@@ -1,57 +1,232 @@
 package gincore
 
 import (
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/nk-bm/gocore/env"
 	"github.com/nk-bm/gocore/gincore/ginmw"
+	"github.com/nk-bm/gocore/gincore/health"
+	"github.com/nk-bm/gocore/gostore"
+	"github.com/nk-bm/gocore/goutils"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 )
 
 type Options struct {
 	EnableCORS                bool `env:"GIN_ENABLE_CORS; default:true"`
+	DisableRequestLogger      bool `env:"GIN_DISABLE_REQUEST_LOGGER; default:false"`
 	DisableRequestTime        bool `env:"GIN_DISABLE_REQUEST_TIME; default:false"`
 	DisableHealthCheckHandler bool `env:"GIN_DISABLE_HEALTH_CHECK_HANDLER; default:false"`
+	// EnableVersionHeader adds the X-App-Version header with the service version to every response
+	EnableVersionHeader bool `env:"GIN_ENABLE_VERSION_HEADER; default:false"`
+	// EnableVersionHandler serves the version and commit at /version, without the module list
+	EnableVersionHandler  bool `env:"GIN_ENABLE_VERSION_HANDLER; default:false"`
+	EnableConfigHandler   bool `env:"GIN_ENABLE_CONFIG_HANDLER; default:false"`
+	EnableLogLevelHandler bool `env:"GIN_ENABLE_LOG_LEVEL_HANDLER; default:false"`
+	EnableRoutesHandler   bool `env:"GIN_ENABLE_ROUTES_HANDLER; default:false"`
+	EnableOpenAPI         bool `env:"GIN_ENABLE_OPENAPI; default:false"`
+	EnableRateLimit       bool `env:"GIN_ENABLE_RATE_LIMIT; default:false"`
+	EnableCompression     bool `env:"GIN_ENABLE_COMPRESSION; default:false"`
+	// EnableDecompression accepts gzip request bodies and answers 415 to other encodings.
+	// The body limit applies to the decompressed size
+	EnableDecompression bool `env:"GIN_ENABLE_DECOMPRESSION; default:false"`
+	// EnableIdempotency replays responses of POST, PUT, PATCH and DELETE requests retried with the same Idempotency-Key.
+	// It applies to routes with an auth provider, keys are stored per authenticated user
+	EnableIdempotency     bool `env:"GIN_ENABLE_IDEMPOTENCY; default:false"`
+	EnableSecurityHeaders bool `env:"GIN_ENABLE_SECURITY_HEADERS; default:false"`
+	// EnableCSRF checks CSRF tokens of unsafe requests with one of the GIN_CSRF_SESSION_COOKIES, which it requires.
+	// Requests authenticated by headers are not checked. Use ginmw.CSRFMW to protect only some route groups
+	EnableCSRF bool `env:"GIN_ENABLE_CSRF; default:false"`
+	// EnableMetrics serves Prometheus metrics, the Disable*Metrics options drop groups of them
+	EnableMetrics         bool `env:"GIN_ENABLE_METRICS; default:false"`
+	DisableHTTPMetrics    bool `env:"GIN_DISABLE_HTTP_METRICS; default:false"`
+	DisableDBMetrics      bool `env:"GIN_DISABLE_DB_METRICS; default:false"`
+	DisableRuntimeMetrics bool `env:"GIN_DISABLE_RUNTIME_METRICS; default:false"`
+	// DisableTracing turns off request spans, they are no-ops unless a tracer provider is configured
+	DisableTracing bool `env:"GIN_DISABLE_TRACING; default:false"`
+	// EnableH2C serves HTTP/2 without TLS, for deployments behind a proxy that terminates TLS
+	EnableH2C bool `env:"GIN_ENABLE_H2C; default:false"`
 }
 
 type Config struct {
-	APIPath string `env:"GIN_API_PATH; default:/api/v1"`
-	Port    int    `env:"GIN_PORT; default:8080"`
-	Host    string `env:"GIN_HOST; default:0.0.0.0"`
-	Options Options
+	APIPath    string `env:"GIN_API_PATH; default:/api/v1"`
+	AdminPath  string `env:"GIN_ADMIN_PATH; default:/admin"`
+	AdminToken string `env:"GIN_ADMIN_TOKEN; secret"`
+	Port       int    `env:"GIN_PORT; default:8080"`
+	Host       string `env:"GIN_HOST; default:0.0.0.0"`
+	// UnixSocket makes the server listen on a Unix socket instead of Host and Port
+	UnixSocket string `env:"GIN_UNIX_SOCKET"`
+	// TrustedProxies are the IPs and CIDRs whose X-Forwarded-For and X-Real-IP headers set the client IP.
+	// By default no proxy is trusted and the client IP is the remote address, so clients cannot spoof it
+	TrustedProxies []string `env:"GIN_TRUSTED_PROXIES"`
+	HTTP           HTTPConfig
+	Limits         LimitsConfig
+	Health         HealthConfig
+	TLS            TLSConfig
+	OpenAPI        OpenAPIConfig
+	CORS           ginmw.CORSConfig
+	Compression    ginmw.CompressionConfig
+	// SecurityHeaders are set when EnableSecurityHeaders is on
+	SecurityHeaders ginmw.SecurityHeadersConfig
+	CSRF            ginmw.CSRFConfig
+	RateLimit       ginmw.RateLimitConfig
+	Idempotency     ginmw.IdempotencyConfig
+	Metrics         MetricsConfig
+	AccessLog       ginmw.AccessLogConfig
+	Options         Options
+	// AdminServer moves version, metrics, routes, config and log level endpoints to an AdminServer.
+	// Health probes are served by both, the main server keeps them for the kubelet, which sends no credentials.
+	// It is set by the application rather than loaded from the environment
+	AdminServer bool
+}
+
+func (c *Config) Validate() error {
+	return errors.Join(c.CORS.Validate(), c.RateLimit.Validate(), c.Compression.Validate(),
+		c.SecurityHeaders.Validate(), c.CSRF.Validate(), c.Idempotency.Validate(), c.OpenAPI.Validate(), c.TLS.Validate(), c.validateCSRF())
+}
+
+// validateCSRF requires the session cookies, otherwise any cookie would require a CSRF token,
+// including the analytics cookies sent with Bearer and TMA token requests
+func (c *Config) validateCSRF() error {
+	if c.Options.EnableCSRF && len(c.CSRF.SessionCookies) == 0 {
+		return fmt.Errorf("GIN_CSRF_SESSION_COOKIES must list the session cookies when GIN_ENABLE_CSRF is on")
+	}
+	return nil
 }
 
 type Server struct {
 	config    *Config
 	Router    *gin.Engine
 	APIRouter *gin.RouterGroup
-	logger    *zap.Logger
-}
+	// AdminRouter serves operational endpoints and requires the GIN_ADMIN_TOKEN bearer token
+	AdminRouter *gin.RouterGroup
+	logger      *zap.Logger
 
-type Route struct {
-	Method  string
-	Path    string
-	Handler gin.HandlerFunc
+	authProviders map[string]gin.HandlerFunc
+	routes        []RouteInfo
+	// securitySchemes document auth providers in the OpenAPI spec
+	securitySchemes map[string]securityScheme
+	// cors is nil when EnableCORS is off
+	cors *ginmw.CORSPolicy
+	// rateLimitStore is nil when EnableRateLimit is off
+	rateLimitStore gostore.RateLimitStore
+	rateLimitKey   ginmw.RateLimitKeyFunc
+	// rateLimitByIP is set when the key does not depend on the user, then limiters run before auth
+	rateLimitByIP bool
+	rateLimits    map[string]gostore.RateLimit
+	// idempotencyStore is nil when EnableIdempotency is off
+	idempotencyStore gostore.IdempotencyStore
+	// routeLimits replace the server limits for routes by "METHOD /path"
+	routeLimits map[string]routeLimits
+	// metrics is nil when EnableMetrics is off
+	metrics       *prometheus.Registry
+	metricsServer *http.Server
+	health        *health.Registry
+
+	httpServer *http.Server
+	listener   net.Listener
+	errors     chan error
 }
 
-func NewServer(config Config, logger *zap.Logger) *Server {
-	router := gin.Default()
+// NewServer builds the router with the global middleware enabled by config.
+// It fails on middleware configs that cannot be applied, rather than serving without them
+func NewServer(config Config, logger *zap.Logger) (*Server, error) {
+	router := gin.New()
+	// The client IP keys rate limits and access logs, gin trusts every proxy unless told otherwise
+	if err := router.SetTrustedProxies(config.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+	s := &Server{
+		config:          &config,
+		logger:          logger,
+		Router:          router,
+		securitySchemes: defaultSecuritySchemes(),
+		health:          health.NewRegistry(config.Health.Timeout, config.Health.CacheTTL, logger),
+	}
 
+	// Tracing runs first so that request logs carry the ids of the request span
+	if !config.Options.DisableTracing {
+		router.Use(ginmw.TracingMW())
+	}
+	if !config.Options.DisableRequestLogger {
+		router.Use(ginmw.RequestLoggerMW(logger))
+	}
+	if !config.AccessLog.Disable {
+		router.Use(ginmw.AccessLogMW(logger, config.AccessLog))
+	}
+	// Metrics are recorded outside of recovery so that panics are counted as 500
+	if config.Options.EnableMetrics {
+		if err := s.initMetrics(); err != nil {
+			return nil, err
+		}
+	}
+	// Compression wraps recovery, so that error responses of panics are written through it
+	if config.Options.EnableCompression {
+		router.Use(ginmw.CompressionMW(config.Compression))
+	}
+	router.Use(ginmw.RecoveryMW(logger))
 	if config.Options.EnableCORS {
-		router.Use(ginmw.EnableCORS())
+		var err error
+		if s.cors, err = ginmw.NewCORSPolicy(config.CORS); err != nil {
+			return nil, fmt.Errorf("invalid CORS config: %w", err)
+		}
+		router.Use(ginmw.CORSMW(s.cors))
 	}
 	if !config.Options.DisableRequestTime {
 		router.Use(ginmw.RequestTimeMW())
 	}
+	if config.Options.EnableDecompression {
+		router.Use(ginmw.DecompressMW())
+	}
+	router.Use(ginmw.BodyLimitFuncMW(s.maxBodySize), ginmw.TimeoutFuncMW(s.requestTimeout))
+	if config.Options.EnableSecurityHeaders {
+		router.Use(ginmw.SecurityHeadersMW(config.SecurityHeaders))
+	}
+	// CSRF runs after the body limit, since it may read form posts
+	if config.Options.EnableCSRF {
+		if err := config.validateCSRF(); err != nil {
+			return nil, err
+		}
+		router.Use(ginmw.CSRFMW(config.CSRF))
+	}
+	if config.Options.EnableVersionHeader {
+		router.Use(ginmw.VersionHeaderMW(goutils.GetBuildInfo().Version))
+	}
 	if !config.Options.DisableHealthCheckHandler {
-		router.GET("/health", HealthCheckHandler)
+		s.registerHealthHandlers(router)
+	}
+	if config.Options.EnableVersionHandler && !config.AdminServer {
+		router.GET("/version", VersionHandler)
+	}
+
+	s.APIRouter = router.Group(config.APIPath)
+	s.AdminRouter = router.Group(config.AdminPath, ginmw.AdminTokenMW(config.AdminToken))
+
+	// Metrics list routes and build details, on the main server they are admin endpoints
+	if config.Options.EnableMetrics && config.Metrics.Port == 0 && !config.AdminServer {
+		s.warnMissingAdminToken()
+		router.GET(config.Metrics.Path, ginmw.AdminTokenMW(config.AdminToken), gin.WrapH(s.metricsHandler()))
+	}
+	if config.Options.EnableRateLimit {
+		if err := s.initRateLimit(); err != nil {
+			return nil, err
+		}
+	}
+	if config.Options.EnableIdempotency {
+		s.idempotencyStore = gostore.NewMemoryIdempotencyStore()
 	}
-	return &Server{
-		config:    &config,
-		logger:    logger,
-		Router:    router,
-		APIRouter: router.Group(config.APIPath),
+	if config.Options.EnableOpenAPI {
+		s.registerOpenAPIHandlers()
 	}
+	if config.Options.EnableRoutesHandler && !config.AdminServer {
+		s.warnMissingAdminToken()
+		s.AdminRouter.GET("/routes", RoutesHandler(s))
+	}
+	return s, nil
 }
 
 func (s *Server) RegisterRoutes(routes []Route) {
@@ -60,15 +235,35 @@ func (s *Server) RegisterRoutes(routes []Route) {
 	}
 }
 
+// RegisterRoute registers the route on APIRouter.
+// It panics if the route requires an unknown auth provider, like gin does for conflicting routes
 func (s *Server) RegisterRoute(route Route) {
-	s.APIRouter.Handle(route.Method, route.Path, route.Handler)
+	s.registerRoute(s.APIRouter, route, routeScope{})
+}
+
+// RegisterConfigHandler exposes the configuration dump on AdminRouter when EnableConfigHandler is set
+// and the endpoints are not moved to an AdminServer
+func (s *Server) RegisterConfigHandler(config any, opts env.LoadOptions) {
+	if !s.config.Options.EnableConfigHandler || s.config.AdminServer {
+		return
+	}
+	s.warnMissingAdminToken()
+	s.AdminRouter.GET("/config", ConfigHandler(config, opts))
+}
+
+// RegisterLogLevelHandlers exposes log level control on AdminRouter when EnableLogLevelHandler is set
+// and the endpoints are not moved to an AdminServer
+func (s *Server) RegisterLogLevelHandlers(levels LevelController) {
+	if !s.config.Options.EnableLogLevelHandler || s.config.AdminServer {
+		return
+	}
+	s.warnMissingAdminToken()
+	s.AdminRouter.GET("/log-level", LogLevelsHandler(levels))
+	s.AdminRouter.PUT("/log-level", SetLogLevelHandler(levels))
 }
 
-func (s *Server) Start() error {
-	if s.config.Port == 0 {
-		return fmt.Errorf("port is not set")
+func (s *Server) warnMissingAdminToken() {
+	if s.config.AdminToken == "" {
+		s.logger.Warn("GIN_ADMIN_TOKEN is not set, admin endpoints will reject all requests")
 	}
-	addr := fmt.Sprintf("%s:%d", s.config.Host, s.config.Port)
-	s.logger.Info("Server started", zap.String("addr", addr))
-	return s.Router.Run(addr)
 }
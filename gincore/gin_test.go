@@ -0,0 +1,37 @@
+package gincore
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/nk-bm/gocore/env"
+	"go.uber.org/zap"
+)
+
+func TestCSRFRequiresSessionCookies(t *testing.T) {
+	var config Config
+	if err := env.LoadEnv(&config); err != nil {
+		t.Fatal(err)
+	}
+	config.Options.EnableCSRF = true
+	if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "GIN_CSRF_SESSION_COOKIES") {
+		t.Errorf("Validate() = %v, want the missing session cookies", err)
+	}
+	if _, err := NewServer(config, zap.NewNop()); err == nil {
+		t.Error("NewServer succeeded without session cookies")
+	}
+}
+
+func TestCSRFPassesHeaderAuthentication(t *testing.T) {
+	s := newTestServer(t, func(config *Config) {
+		config.Options.EnableCSRF = true
+		config.CSRF.SessionCookies = []string{"session"}
+	})
+	s.RegisterRoute(Route{Method: http.MethodPost, Path: "/orders", Handler: okHandler})
+
+	assertStatus(t, serve(s, http.MethodPost, "/api/v1/orders", "", "Authorization: Bearer token", "Cookie: locale=ru"),
+		http.StatusOK)
+	assertStatus(t, serve(s, http.MethodPost, "/api/v1/orders", "", "Authorization: Bearer token", "Cookie: session=s1"),
+		http.StatusForbidden)
+}
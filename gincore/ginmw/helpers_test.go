@@ -0,0 +1,37 @@
+package ginmw
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newTestRouter returns a router running middleware before the handler of every method at /test
+func newTestRouter(handler gin.HandlerFunc, middleware ...gin.HandlerFunc) *gin.Engine {
+	router := gin.New()
+	router.Use(middleware...)
+	router.Any("/test", handler)
+	return router
+}
+
+// serve sends a request to the router, headers are "Name: value" pairs
+func serve(router *gin.Engine, method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	for _, header := range headers {
+		name, value, _ := strings.Cut(header, ":")
+		req.Header.Set(name, strings.TrimSpace(value))
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
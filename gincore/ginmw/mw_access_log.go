@@ -0,0 +1,74 @@
+package ginmw
+
+import (
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nk-bm/gocore/gincore/static"
+	"go.uber.org/zap"
+)
+
+type AccessLogConfig struct {
+	Disable   bool     `env:"GIN_DISABLE_ACCESS_LOG; default:false"`
+	SkipPaths []string `env:"GIN_ACCESS_LOG_SKIP_PATHS; default:/health,/health/live,/health/ready,/health/startup"`
+	// SuccessSampleRate is the fraction of requests with status below 400 that are logged.
+	// Values outside (0, 1) log every request
+	SuccessSampleRate float64 `env:"GIN_ACCESS_LOG_SUCCESS_SAMPLE_RATE; default:1"`
+}
+
+// AccessLogMW logs every request through zap. Server errors are logged at error level,
+// client errors at warn and the rest at info
+func AccessLogMW(logger *zap.Logger, config AccessLogConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+		if slices.Contains(config.SkipPaths, path) || slices.Contains(config.SkipPaths, c.FullPath()) {
+			c.Next()
+			return
+		}
+
+		c.Next()
+
+		status := c.Writer.Status()
+		if status < 400 && config.SuccessSampleRate > 0 && config.SuccessSampleRate < 1 && rand.Float64() >= config.SuccessSampleRate {
+			return
+		}
+
+		fields := []zap.Field{
+			zap.Int("status", status),
+			zap.Duration("latency", time.Since(start)),
+			zap.Int("bytes", max(c.Writer.Size(), 0)),
+			zap.String("client_ip", c.ClientIP()),
+			zap.String("path", path),
+			zap.String("user_agent", c.Request.UserAgent()),
+		}
+		// Without RequestLoggerMW the request logger does not carry the method and route
+		if _, ok := c.Get(static.REQUEST_ID); !ok {
+			fields = append(fields, zap.String("method", c.Request.Method), zap.String("route", c.FullPath()))
+		}
+		if errs := c.Errors.ByType(gin.ErrorTypePrivate); len(errs) > 0 {
+			fields = append(fields, zap.String("errors", errs.String()))
+		}
+
+		l := ctxLoggerOr(c, logger)
+		switch {
+		case status >= 500:
+			l.Error("Request completed", fields...)
+		case status >= 400:
+			l.Warn("Request completed", fields...)
+		default:
+			l.Info("Request completed", fields...)
+		}
+	}
+}
+
+func ctxLoggerOr(c *gin.Context, fallback *zap.Logger) *zap.Logger {
+	if logger, ok := c.Get(static.LOGGER); ok {
+		if l, ok := logger.(*zap.Logger); ok {
+			return l
+		}
+	}
+	return fallback
+}
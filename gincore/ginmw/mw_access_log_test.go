@@ -0,0 +1,88 @@
+package ginmw
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestAccessLogMW(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	router := gin.New()
+	router.Use(AccessLogMW(zap.New(core), AccessLogConfig{SkipPaths: []string{"/health"}}))
+	router.GET("/users/:id", func(c *gin.Context) {
+		c.String(http.StatusOK, "hello")
+	})
+	router.GET("/missing/:id", func(c *gin.Context) {
+		c.Status(http.StatusNotFound)
+	})
+	router.GET("/fail", func(c *gin.Context) {
+		c.Status(http.StatusInternalServerError)
+	})
+	router.GET("/health", func(c *gin.Context) {})
+
+	serve(router, http.MethodGet, "/users/42", "", "User-Agent: test-agent")
+	serve(router, http.MethodGet, "/missing/1", "")
+	serve(router, http.MethodGet, "/fail", "")
+	serve(router, http.MethodGet, "/health", "")
+
+	entries := logs.All()
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3 with /health skipped", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	for key, want := range map[string]any{
+		"status": int64(200), "bytes": int64(5), "path": "/users/42", "route": "/users/:id",
+		"method": "GET", "user_agent": "test-agent", "client_ip": "192.0.2.1",
+	} {
+		if fields[key] != want {
+			t.Errorf("%s = %v, want %v", key, fields[key], want)
+		}
+	}
+	if _, ok := fields["latency"]; !ok {
+		t.Error("latency is missing")
+	}
+	wantLevels := []zapcore.Level{zapcore.InfoLevel, zapcore.WarnLevel, zapcore.ErrorLevel}
+	for i, entry := range entries {
+		if entry.Level != wantLevels[i] {
+			t.Errorf("entry %d level = %v, want %v", i, entry.Level, wantLevels[i])
+		}
+	}
+}
+
+func TestAccessLogMWSampling(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	router := gin.New()
+	router.Use(AccessLogMW(zap.New(core), AccessLogConfig{SuccessSampleRate: 1e-12}))
+	router.GET("/ok", func(c *gin.Context) {})
+	router.GET("/bad", func(c *gin.Context) { c.Status(http.StatusBadRequest) })
+
+	for i := 0; i < 10; i++ {
+		serve(router, http.MethodGet, "/ok", "")
+	}
+	serve(router, http.MethodGet, "/bad", "")
+
+	// Errors are never sampled out
+	if entries := logs.All(); len(entries) != 1 || entries[0].ContextMap()["status"] != int64(400) {
+		t.Fatalf("got %d entries, want only the client error", len(entries))
+	}
+}
+
+func TestAccessLogMWUsesRequestLogger(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(core)
+	router := newTestRouter(func(c *gin.Context) {
+		AddLogFields(c, zap.Int64("user_id", 42))
+	}, RequestLoggerMW(logger), AccessLogMW(logger, AccessLogConfig{}))
+
+	serve(router, http.MethodGet, "/test", "", "X-Request-ID: req-1")
+
+	fields := logs.FilterMessage("Request completed").All()[0].ContextMap()
+	if fields["request_id"] != "req-1" || fields["user_id"] != int64(42) || fields["route"] != "/test" {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+}
@@ -0,0 +1,23 @@
+package ginmw
+
+import (
+	"crypto/subtle"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nk-bm/gocore/gincore/response"
+	"github.com/nk-bm/gocore/goutils"
+)
+
+// AdminTokenMW allows requests with "Authorization: Bearer <token>". An empty token rejects every request
+func AdminTokenMW(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		got, err := goutils.ExtractGinToken(c, "Authorization", "Bearer")
+		if token == "" || err != nil || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			response.Unauthorized(c)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
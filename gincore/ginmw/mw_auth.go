@@ -6,6 +6,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/nk-bm/gocore/gincore/response"
 	"github.com/nk-bm/gocore/goutils"
+	"go.uber.org/zap"
 )
 
 func AuthMW(secretKey string, idKey, authType string) gin.HandlerFunc {
@@ -25,6 +26,7 @@ func AuthMW(secretKey string, idKey, authType string) gin.HandlerFunc {
 		}
 
 		c.Set(idKey, id)
+		AddLogFields(c, zap.Int64("user_id", id))
 		c.Next()
 	}
 }
@@ -0,0 +1,30 @@
+package ginmw
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nk-bm/gocore/gincore/response"
+)
+
+// BasicAuthMW allows requests with the given HTTP Basic credentials and asks browsers for them otherwise
+func BasicAuthMW(username, password, realm string) gin.HandlerFunc {
+	// Hashes have equal length, so the comparison does not reveal the length of the credentials
+	wantUser, wantPassword := sha256.Sum256([]byte(username)), sha256.Sum256([]byte(password))
+	challenge := `Basic realm="` + realm + `", charset="UTF-8"`
+	return func(c *gin.Context) {
+		user, pass, ok := c.Request.BasicAuth()
+		gotUser, gotPassword := sha256.Sum256([]byte(user)), sha256.Sum256([]byte(pass))
+		userMatch := subtle.ConstantTimeCompare(gotUser[:], wantUser[:])
+		passwordMatch := subtle.ConstantTimeCompare(gotPassword[:], wantPassword[:])
+		if !ok || userMatch&passwordMatch != 1 {
+			c.Header("WWW-Authenticate", challenge)
+			response.Unauthorized(c)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,52 @@
+package ginmw
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nk-bm/gocore/gincore/response"
+)
+
+const bodyLimitKey = "gocore.body_limit"
+
+// limitedBody is the body installed by BodyLimitMW and the body it limits
+type limitedBody struct {
+	limited, underlying io.ReadCloser
+}
+
+// BodyLimitMW answers 413 to requests with a declared body larger than maxBytes and makes reading beyond
+// maxBytes fail with *http.MaxBytesError, which Handle answers with 413 as well. The innermost limit applies,
+// so a route group can raise or lower the server limit; maxBytes <= 0 removes the limit
+func BodyLimitMW(maxBytes int64) gin.HandlerFunc {
+	return BodyLimitFuncMW(func(*gin.Context) int64 { return maxBytes })
+}
+
+// BodyLimitFuncMW is BodyLimitMW with the limit chosen per request, for example by route
+func BodyLimitFuncMW(limit func(c *gin.Context) int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		maxBytes := limit(c)
+		body := c.Request.Body
+		if value, ok := c.Get(bodyLimitKey); ok {
+			// The body is replaced only if no middleware in between wrapped it, for example to decompress it
+			if outer := value.(limitedBody); outer.limited == body {
+				body = outer.underlying
+			}
+		}
+
+		if maxBytes <= 0 || body == nil || body == http.NoBody {
+			c.Request.Body = body
+			c.Next()
+			return
+		}
+		if c.Request.ContentLength > maxBytes {
+			response.PayloadTooLarge(c)
+			c.Abort()
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, body, maxBytes)
+		c.Set(bodyLimitKey, limitedBody{limited: c.Request.Body, underlying: body})
+		c.Next()
+	}
+}
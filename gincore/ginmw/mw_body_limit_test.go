@@ -0,0 +1,67 @@
+package ginmw
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readBodyHandler answers 413 when reading the body exceeds the limit, like Handle does
+func readBodyHandler(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		c.Status(http.StatusRequestEntityTooLarge)
+		return
+	}
+	c.String(http.StatusOK, "%d", len(body))
+}
+
+// serveStream sends body without Content-Length, so that only reading it reveals its size
+func serveStream(router *gin.Engine, body string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/test", io.NopCloser(strings.NewReader(body)))
+	req.ContentLength = -1
+	for _, header := range headers {
+		name, value, _ := strings.Cut(header, ":")
+		req.Header.Set(name, strings.TrimSpace(value))
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestBodyLimitMW(t *testing.T) {
+	router := newTestRouter(readBodyHandler, BodyLimitMW(8))
+
+	if w := serve(router, http.MethodPost, "/test", "12345678"); w.Code != http.StatusOK || w.Body.String() != "8" {
+		t.Errorf("body at the limit: status %d, body %s", w.Code, w.Body.String())
+	}
+	if w := serve(router, http.MethodPost, "/test", "123456789"); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("declared body over the limit: status %d, want 413", w.Code)
+	}
+	if w := serveStream(router, "123456789"); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("streamed body over the limit: status %d, want 413", w.Code)
+	}
+}
+
+func TestBodyLimitMWInnermostApplies(t *testing.T) {
+	raised := newTestRouter(readBodyHandler, BodyLimitMW(4), BodyLimitMW(16))
+	if w := serveStream(raised, "123456789"); w.Code != http.StatusOK {
+		t.Errorf("raised limit: status %d, want 200", w.Code)
+	}
+
+	lowered := newTestRouter(readBodyHandler, BodyLimitMW(16), BodyLimitMW(4))
+	if w := serveStream(lowered, "123456789"); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("lowered limit: status %d, want 413", w.Code)
+	}
+
+	removed := newTestRouter(readBodyHandler, BodyLimitMW(4), BodyLimitMW(0))
+	if w := serveStream(removed, "123456789"); w.Code != http.StatusOK {
+		t.Errorf("removed limit: status %d, want 200", w.Code)
+	}
+}
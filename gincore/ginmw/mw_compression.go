@@ -0,0 +1,275 @@
+package ginmw
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+)
+
+// Response encodings supported by CompressionMW
+const (
+	EncodingBrotli = "br"
+	EncodingGzip   = "gzip"
+)
+
+type CompressionConfig struct {
+	// Algorithms are used in this order of preference when the client accepts several with the same weight
+	Algorithms []string `env:"GIN_COMPRESSION_ALGORITHMS; default:br,gzip"`
+	// Level from 1 (fastest) to 9 (smallest), 0 uses the default level of each algorithm
+	Level int `env:"GIN_COMPRESSION_LEVEL; default:0"`
+	// MinSize is the smallest response in bytes that is compressed
+	MinSize int `env:"GIN_COMPRESSION_MIN_SIZE; default:1024"`
+	// ContentTypes are media types like "application/json" or wildcards like "text/*"
+	ContentTypes []string `env:"GIN_COMPRESSION_CONTENT_TYPES; default:text/*,application/json,application/problem+json,application/javascript,application/xml,image/svg+xml"`
+}
+
+func (c CompressionConfig) Validate() error {
+	for _, algorithm := range c.Algorithms {
+		if algorithm != EncodingBrotli && algorithm != EncodingGzip {
+			return fmt.Errorf("invalid GIN_COMPRESSION_ALGORITHMS: unknown algorithm %q", algorithm)
+		}
+	}
+	if c.Level < 0 || c.Level > 9 {
+		return fmt.Errorf("invalid GIN_COMPRESSION_LEVEL %d: must be between 0 and 9", c.Level)
+	}
+	return nil
+}
+
+// encoder is implemented by *gzip.Writer and *brotli.Writer
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+type compressor struct {
+	algorithms   []string
+	minSize      int
+	contentTypes []string
+	pools        map[string]*sync.Pool
+}
+
+// CompressionMW compresses responses with brotli or gzip, as negotiated by Accept-Encoding.
+// Responses smaller than MinSize, of other content types, partial or already encoded are sent as is.
+// Streamed responses are compressed once they are flushed
+func CompressionMW(config CompressionConfig) gin.HandlerFunc {
+	comp := &compressor{
+		minSize: config.MinSize,
+		pools:   make(map[string]*sync.Pool),
+	}
+	for _, contentType := range config.ContentTypes {
+		comp.contentTypes = append(comp.contentTypes, strings.ToLower(strings.TrimSpace(contentType)))
+	}
+	for _, algorithm := range config.Algorithms {
+		level := config.Level
+		switch algorithm {
+		case EncodingGzip:
+			if level == 0 {
+				level = gzip.DefaultCompression
+			}
+			comp.pools[algorithm] = &sync.Pool{New: func() any {
+				w, _ := gzip.NewWriterLevel(io.Discard, level)
+				return w
+			}}
+		case EncodingBrotli:
+			if level == 0 {
+				level = brotli.DefaultCompression
+			}
+			comp.pools[algorithm] = &sync.Pool{New: func() any {
+				return brotli.NewWriterLevel(io.Discard, level)
+			}}
+		default:
+			continue
+		}
+		comp.algorithms = append(comp.algorithms, algorithm)
+	}
+
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		encoding := comp.negotiate(c.GetHeader("Accept-Encoding"))
+		if encoding == "" || c.Request.Method == http.MethodHead || c.GetHeader("Upgrade") != "" {
+			c.Next()
+			return
+		}
+
+		writer := &compressWriter{ResponseWriter: c.Writer, compressor: comp, encoding: encoding}
+		c.Writer = writer
+		c.Next()
+		writer.finish()
+		c.Writer = writer.ResponseWriter
+	}
+}
+
+// negotiate returns the preferred algorithm with the highest weight in the Accept-Encoding header
+func (comp *compressor) negotiate(header string) string {
+	if header == "" {
+		return ""
+	}
+	weights := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		weight := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+				weight = parsed
+			}
+		}
+		weights[strings.ToLower(strings.TrimSpace(name))] = weight
+	}
+
+	best, bestWeight := "", 0.0
+	for _, algorithm := range comp.algorithms {
+		weight, ok := weights[algorithm]
+		if !ok {
+			weight = weights["*"]
+		}
+		if weight > bestWeight {
+			best, bestWeight = algorithm, weight
+		}
+	}
+	return best
+}
+
+func (comp *compressor) compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, pattern := range comp.contentTypes {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(mediaType, prefix) {
+				return true
+			}
+		} else if mediaType == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// compressWriter buffers the response until MinSize bytes are written, the handler flushes or returns,
+// and then decides whether to compress it
+type compressWriter struct {
+	gin.ResponseWriter
+	compressor *compressor
+	encoding   string
+
+	buf     []byte
+	decided bool
+	encoder encoder
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, p...)
+		if len(w.buf) < w.compressor.minSize {
+			return len(p), nil
+		}
+		if err := w.decide(); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if w.encoder != nil {
+		return w.encoder.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// WriteHeaderNow sends the headers, so the decision cannot be postponed
+func (w *compressWriter) WriteHeaderNow() {
+	if !w.decided {
+		_ = w.decide()
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *compressWriter) Written() bool {
+	return len(w.buf) > 0 || w.ResponseWriter.Written()
+}
+
+func (w *compressWriter) Size() int {
+	return w.ResponseWriter.Size() + len(w.buf)
+}
+
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		_ = w.decide()
+	}
+	if w.encoder != nil {
+		_ = w.encoder.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *compressWriter) decide() error {
+	w.decided = true
+	if w.shouldCompress() {
+		header := w.Header()
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		// The compressed representation differs, so a strong validator would be wrong
+		if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			header.Set("ETag", "W/"+etag)
+		}
+		w.encoder = w.compressor.pools[w.encoding].Get().(encoder)
+		w.encoder.Reset(w.ResponseWriter)
+	}
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if w.encoder != nil {
+		_, err := w.encoder.Write(buf)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+func (w *compressWriter) shouldCompress() bool {
+	if len(w.buf) == 0 || len(w.buf) < w.compressor.minSize {
+		return false
+	}
+	status := w.Status()
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusPartialContent || status == http.StatusNotModified {
+		return false
+	}
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" || header.Get("Content-Range") != "" {
+		return false
+	}
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(w.buf)
+		header.Set("Content-Type", contentType)
+	}
+	return w.compressor.compressible(contentType)
+}
+
+// finish writes what is buffered and completes the compressed stream
+func (w *compressWriter) finish() {
+	if !w.decided {
+		_ = w.decide()
+	}
+	if w.encoder != nil {
+		_ = w.encoder.Close()
+		w.encoder.Reset(io.Discard)
+		w.compressor.pools[w.encoding].Put(w.encoder)
+		w.encoder = nil
+	}
+}
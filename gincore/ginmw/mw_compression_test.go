@@ -0,0 +1,90 @@
+package ginmw
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+)
+
+func testCompressionConfig() CompressionConfig {
+	return CompressionConfig{
+		Algorithms:   []string{EncodingBrotli, EncodingGzip},
+		MinSize:      100,
+		ContentTypes: []string{"text/*", "application/json"},
+	}
+}
+
+func textHandler(size int, contentType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, contentType, []byte(strings.Repeat("a", size)))
+	}
+}
+
+func TestCompressionMW(t *testing.T) {
+	router := newTestRouter(textHandler(1000, "text/plain"), CompressionMW(testCompressionConfig()))
+
+	w := serve(router, http.MethodGet, "/test", "", "Accept-Encoding: gzip")
+	if w.Header().Get("Content-Encoding") != EncodingGzip || w.Header().Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("headers = %v, want gzip", w.Header())
+	}
+	reader, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := io.ReadAll(reader); len(body) != 1000 {
+		t.Errorf("decompressed %d bytes, want 1000", len(body))
+	}
+
+	w = serve(router, http.MethodGet, "/test", "", "Accept-Encoding: gzip, br")
+	if w.Header().Get("Content-Encoding") != EncodingBrotli {
+		t.Fatalf("Content-Encoding = %q, want the preferred br", w.Header().Get("Content-Encoding"))
+	}
+	if body, _ := io.ReadAll(brotli.NewReader(w.Body)); len(body) != 1000 {
+		t.Errorf("decompressed %d bytes, want 1000", len(body))
+	}
+
+	if w := serve(router, http.MethodGet, "/test", "", "Accept-Encoding: br;q=0.5, gzip"); w.Header().Get("Content-Encoding") != EncodingGzip {
+		t.Errorf("Content-Encoding = %q, want gzip with the higher weight", w.Header().Get("Content-Encoding"))
+	}
+	if w := serve(router, http.MethodGet, "/test", ""); w.Header().Get("Content-Encoding") != "" || w.Body.Len() != 1000 {
+		t.Errorf("without Accept-Encoding: headers %v, %d bytes", w.Header(), w.Body.Len())
+	}
+}
+
+func TestCompressionMWSkipsResponses(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler gin.HandlerFunc
+	}{
+		{"small", textHandler(10, "text/plain")},
+		{"other content type", textHandler(1000, "image/png")},
+		{"already encoded", func(c *gin.Context) {
+			c.Header("Content-Encoding", "br")
+			textHandler(1000, "text/plain")(c)
+		}},
+	}
+	for _, tt := range tests {
+		router := newTestRouter(tt.handler, CompressionMW(testCompressionConfig()))
+		w := serve(router, http.MethodGet, "/test", "", "Accept-Encoding: gzip")
+		if w.Header().Get("Content-Encoding") == EncodingGzip {
+			t.Errorf("%s: response is compressed", tt.name)
+		}
+	}
+}
+
+func TestCompressionMWWeakensETag(t *testing.T) {
+	router := newTestRouter(func(c *gin.Context) {
+		c.Header("ETag", `"v1"`)
+		textHandler(1000, "application/json")(c)
+	}, CompressionMW(testCompressionConfig()))
+
+	w := serve(router, http.MethodGet, "/test", "", "Accept-Encoding: gzip")
+	if got := w.Header().Get("ETag"); got != `W/"v1"` {
+		t.Errorf("ETag = %s, want the weak validator", got)
+	}
+}
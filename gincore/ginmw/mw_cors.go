@@ -1,19 +1,264 @@
 package ginmw
 
-import "github.com/gin-gonic/gin"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
-func EnableCORS() gin.HandlerFunc {
+	"github.com/gin-gonic/gin"
+)
+
+// CORSConfig describes which cross-origin requests browsers may make.
+// AllowOrigins entries are exact origins ("https://app.example.com"), wildcard subdomains
+// ("https://*.example.com"), regular expressions prefixed with "regex:" or "*" for any origin
+type CORSConfig struct {
+	AllowOrigins []string `env:"GIN_CORS_ALLOW_ORIGINS; default:*"`
+	AllowMethods []string `env:"GIN_CORS_ALLOW_METHODS; default:GET,POST,PUT,PATCH,DELETE,HEAD,OPTIONS"`
+	// AllowHeaders may contain "*" to allow every header the browser asks for
+	AllowHeaders  []string `env:"GIN_CORS_ALLOW_HEADERS; default:Accept,Accept-Language,Authorization,Cache-Control,Content-Language,Content-Type,Origin,X-Requested-With,X-CSRF-Token,X-Request-ID,traceparent,X-TMA-Token"`
+	ExposeHeaders []string `env:"GIN_CORS_EXPOSE_HEADERS; default:X-Request-ID"`
+	// AllowCredentials answers with the request origin instead of "*", as browsers require for credentialed requests.
+	// It cannot be combined with the "*" origin, which would let any website make requests with the user's cookies
+	AllowCredentials bool          `env:"GIN_CORS_ALLOW_CREDENTIALS; default:false"`
+	MaxAge           time.Duration `env:"GIN_CORS_MAX_AGE; default:12h"`
+}
+
+func (c CORSConfig) Validate() error {
+	if c.AllowCredentials && slices.Contains(c.AllowOrigins, "*") {
+		return fmt.Errorf("GIN_CORS_ALLOW_CREDENTIALS cannot be combined with the \"*\" origin, list the allowed origins")
+	}
+	_, err := compileOrigins(c.AllowOrigins)
+	return err
+}
+
+// CORSPolicy applies a CORS config, or the config of the longest matching path prefix override
+type CORSPolicy struct {
+	mu        sync.RWMutex
+	base      *corsRules
+	overrides []corsOverride
+}
+
+type corsOverride struct {
+	prefix string
+	rules  *corsRules
+}
+
+type corsRules struct {
+	config        CORSConfig
+	anyOrigin     bool
+	origins       []*regexp.Regexp
+	exact         []string
+	allowMethods  string
+	allowHeaders  string
+	anyHeader     bool
+	exposeHeaders string
+	maxAge        string
+}
+
+// NewCORSPolicy compiles the config, it fails if the config does not pass Validate
+func NewCORSPolicy(config CORSConfig) (*CORSPolicy, error) {
+	rules, err := newCORSRules(config)
+	if err != nil {
+		return nil, err
+	}
+	return &CORSPolicy{base: rules}, nil
+}
+
+// Override applies config to requests with paths under prefix. An invalid config is not applied
+func (p *CORSPolicy) Override(prefix string, config CORSConfig) error {
+	rules, err := newCORSRules(config)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.overrides = append(p.overrides, corsOverride{prefix: prefix, rules: rules})
+	slices.SortStableFunc(p.overrides, func(a, b corsOverride) int {
+		return len(b.prefix) - len(a.prefix)
+	})
+	return nil
+}
+
+func (p *CORSPolicy) rules(path string) *corsRules {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, override := range p.overrides {
+		if pathHasPrefix(path, override.prefix) {
+			return override.rules
+		}
+	}
+	return p.base
+}
+
+// pathHasPrefix matches whole path segments, so /api does not match /apis
+func pathHasPrefix(path, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return path == prefix || strings.HasPrefix(path, prefix+"/") || prefix == ""
+}
+
+func newCORSRules(config CORSConfig) (*corsRules, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	origins, _ := compileOrigins(config.AllowOrigins)
+	rules := &corsRules{
+		config:        config,
+		origins:       origins,
+		allowMethods:  strings.ToUpper(strings.Join(config.AllowMethods, ", ")),
+		allowHeaders:  strings.Join(config.AllowHeaders, ", "),
+		anyHeader:     slices.Contains(config.AllowHeaders, "*"),
+		exposeHeaders: strings.Join(config.ExposeHeaders, ", "),
+	}
+	if config.MaxAge > 0 {
+		rules.maxAge = strconv.Itoa(int(config.MaxAge.Seconds()))
+	}
+	for _, origin := range config.AllowOrigins {
+		switch {
+		case origin == "*":
+			rules.anyOrigin = true
+		case !strings.HasPrefix(origin, "regex:") && !strings.Contains(origin, "*"):
+			rules.exact = append(rules.exact, strings.ToLower(strings.TrimSuffix(origin, "/")))
+		}
+	}
+	return rules, nil
+}
+
+// compileOrigins compiles wildcard and regex origins, exact origins and "*" are matched without them
+func compileOrigins(origins []string) ([]*regexp.Regexp, error) {
+	var (
+		compiled []*regexp.Regexp
+		errs     []error
+	)
+	for _, origin := range origins {
+		var pattern string
+		switch {
+		case origin == "*":
+			continue
+		case strings.HasPrefix(origin, "regex:"):
+			// The whole origin must match, so "https://app\.example\.com" does not allow "https://app.example.com.evil.io"
+			pattern = "^(?:" + strings.TrimPrefix(origin, "regex:") + ")$"
+		case strings.Contains(origin, "*"):
+			// "*." matches one or more subdomain labels
+			pattern = "^" + strings.ReplaceAll(regexp.QuoteMeta(strings.ToLower(origin)), `\*\.`, `([a-z0-9-]+\.)+`) + "$"
+		default:
+			continue
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid CORS origin %q: %w", origin, err))
+			continue
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, errors.Join(errs...)
+}
+
+func (r *corsRules) allowOrigin(origin string) bool {
+	if r.anyOrigin {
+		return true
+	}
+	lower := strings.ToLower(origin)
+	if slices.Contains(r.exact, lower) {
+		return true
+	}
+	for _, re := range r.origins {
+		if re.MatchString(lower) || re.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// CORSMW answers preflight requests and adds CORS headers to cross-origin requests
+func CORSMW(policy *CORSPolicy) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+		rules := policy.rules(c.Request.URL.Path)
+		header := c.Writer.Header()
+		// "*" without credentials is the same for every origin, otherwise the response depends on it,
+		// including responses to requests without an Origin, so that caches do not serve them cross-origin
+		wildcard := rules.anyOrigin && !rules.config.AllowCredentials
+		if !wildcard {
+			header.Add("Vary", "Origin")
+		}
+
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			// With "*" the headers are sent anyway, so a cached response is valid for any origin
+			if wildcard {
+				header.Set("Access-Control-Allow-Origin", "*")
+				if rules.exposeHeaders != "" {
+					header.Set("Access-Control-Expose-Headers", rules.exposeHeaders)
+				}
+			}
+			c.Next()
+			return
+		}
+
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		if preflight {
+			header.Add("Vary", "Access-Control-Request-Method")
+			header.Add("Vary", "Access-Control-Request-Headers")
+		}
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
+		if !rules.allowOrigin(origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusNoContent)
+				return
+			}
+			c.Next()
 			return
 		}
 
-		c.Next()
+		if wildcard {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+		if rules.config.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if rules.exposeHeaders != "" {
+				header.Set("Access-Control-Expose-Headers", rules.exposeHeaders)
+			}
+			c.Next()
+			return
+		}
+
+		header.Set("Access-Control-Allow-Methods", rules.allowMethods)
+		if rules.anyHeader {
+			// "*" is not honored for credentialed requests, so the requested headers are echoed
+			if requested := c.GetHeader("Access-Control-Request-Headers"); requested != "" {
+				header.Set("Access-Control-Allow-Headers", requested)
+			}
+		} else if rules.allowHeaders != "" {
+			header.Set("Access-Control-Allow-Headers", rules.allowHeaders)
+		}
+		if rules.maxAge != "" {
+			header.Set("Access-Control-Max-Age", rules.maxAge)
+		}
+		c.AbortWithStatus(http.StatusNoContent)
 	}
 }
+
+// EnableCORS allows every origin without credentials, answering with "*".
+//
+// Deprecated: use CORSMW with a CORSConfig listing the allowed origins
+func EnableCORS() gin.HandlerFunc {
+	policy, _ := NewCORSPolicy(CORSConfig{
+		AllowOrigins:  []string{"*"},
+		AllowMethods:  []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"},
+		AllowHeaders:  []string{"*"},
+		ExposeHeaders: []string{"X-Request-ID"},
+		MaxAge:        12 * time.Hour,
+	})
+	return CORSMW(policy)
+}
@@ -0,0 +1,162 @@
+package ginmw
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func okHandler(c *gin.Context) {
+	c.Status(http.StatusOK)
+}
+
+func newCORSTestRouter(t *testing.T, config CORSConfig) (*gin.Engine, *CORSPolicy) {
+	t.Helper()
+	policy, err := NewCORSPolicy(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := gin.New()
+	router.Use(CORSMW(policy))
+	router.Any("/test", okHandler)
+	router.Any("/partner/test", okHandler)
+	return router, policy
+}
+
+func TestCORSOrigins(t *testing.T) {
+	router, _ := newCORSTestRouter(t, CORSConfig{
+		AllowOrigins: []string{
+			"https://app.example.com",
+			"https://*.example.org",
+			`regex:https://app-[0-9]+\.example\.net`,
+		},
+		AllowCredentials: true,
+	})
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"https://app.example.com", true},
+		{"HTTPS://APP.EXAMPLE.COM", true},
+		{"https://evil.com", false},
+		{"https://a.b.example.org", true},
+		{"https://example.org", false},
+		{"https://x.example.org.evil.io", false},
+		{"https://app-1.example.net", true},
+		{"https://app-1.example.net.evil.io", false},
+		{"https://evil.io/https://app-1.example.net", false},
+	}
+	for _, tt := range tests {
+		w := serve(router, http.MethodGet, "/test", "", "Origin: "+tt.origin)
+		got := w.Header().Get("Access-Control-Allow-Origin")
+		if allowed := got == tt.origin; allowed != tt.allowed {
+			t.Errorf("%s: Access-Control-Allow-Origin = %q, want allowed %v", tt.origin, got, tt.allowed)
+		}
+		if tt.allowed && w.Header().Get("Access-Control-Allow-Credentials") != "true" {
+			t.Errorf("%s: credentials are not allowed", tt.origin)
+		}
+		if w.Header().Get("Vary") != "Origin" {
+			t.Errorf("%s: Vary = %q, want Origin", tt.origin, w.Header().Get("Vary"))
+		}
+	}
+}
+
+func TestCORSWildcardWithoutCredentials(t *testing.T) {
+	router, _ := newCORSTestRouter(t, CORSConfig{AllowOrigins: []string{"*"}, ExposeHeaders: []string{"X-Request-ID"}})
+
+	w := serve(router, http.MethodGet, "/test", "", "Origin: https://any.site")
+	if w.Header().Get("Access-Control-Allow-Origin") != "*" || w.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Errorf("headers = %v, want * without credentials", w.Header())
+	}
+	if w.Header().Get("Access-Control-Expose-Headers") != "X-Request-ID" {
+		t.Errorf("Access-Control-Expose-Headers = %q", w.Header().Get("Access-Control-Expose-Headers"))
+	}
+}
+
+func TestCORSWithoutOrigin(t *testing.T) {
+	router, _ := newCORSTestRouter(t, CORSConfig{AllowOrigins: []string{"https://app.example.com"}})
+	w := serve(router, http.MethodGet, "/test", "")
+	if w.Header().Get("Vary") != "Origin" || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("headers = %v, want Vary: Origin without CORS headers", w.Header())
+	}
+
+	router, _ = newCORSTestRouter(t, CORSConfig{AllowOrigins: []string{"*"}})
+	w = serve(router, http.MethodGet, "/test", "")
+	if w.Header().Get("Vary") != "" || w.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Errorf("headers = %v, want * without Vary", w.Header())
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	router, _ := newCORSTestRouter(t, CORSConfig{
+		AllowOrigins: []string{"https://app.example.com"},
+		AllowMethods: []string{"get", "post"},
+		AllowHeaders: []string{"Content-Type", "Authorization"},
+		MaxAge:       time.Hour,
+	})
+
+	w := serve(router, http.MethodOptions, "/test", "",
+		"Origin: https://app.example.com", "Access-Control-Request-Method: POST")
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204", w.Code)
+	}
+	for name, want := range map[string]string{
+		"Access-Control-Allow-Origin":  "https://app.example.com",
+		"Access-Control-Allow-Methods": "GET, POST",
+		"Access-Control-Allow-Headers": "Content-Type, Authorization",
+		"Access-Control-Max-Age":       "3600",
+	} {
+		if got := w.Header().Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+
+	w = serve(router, http.MethodOptions, "/test", "", "Origin: https://evil.com", "Access-Control-Request-Method: POST")
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("disallowed preflight: status %d, headers %v", w.Code, w.Header())
+	}
+}
+
+func TestCORSOverride(t *testing.T) {
+	router, policy := newCORSTestRouter(t, CORSConfig{AllowOrigins: []string{"https://app.example.com"}})
+	if err := policy.Override("/partner", CORSConfig{AllowOrigins: []string{"https://partner.io"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := serve(router, http.MethodGet, "/partner/test", "", "Origin: https://partner.io").Header().Get("Access-Control-Allow-Origin"); got != "https://partner.io" {
+		t.Errorf("override: Access-Control-Allow-Origin = %q", got)
+	}
+	if got := serve(router, http.MethodGet, "/test", "", "Origin: https://partner.io").Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("base: Access-Control-Allow-Origin = %q, want none", got)
+	}
+
+	if err := policy.Override("/partner", CORSConfig{AllowOrigins: []string{"regex:("}}); err == nil {
+		t.Error("invalid override is accepted")
+	}
+	if got := serve(router, http.MethodGet, "/partner/test", "", "Origin: https://partner.io").Header().Get("Access-Control-Allow-Origin"); got != "https://partner.io" {
+		t.Errorf("invalid override replaced the valid one: %q", got)
+	}
+}
+
+func TestCORSInvalidConfig(t *testing.T) {
+	configs := []CORSConfig{
+		{AllowOrigins: []string{"*"}, AllowCredentials: true},
+		{AllowOrigins: []string{"regex:("}},
+	}
+	for _, config := range configs {
+		if policy, err := NewCORSPolicy(config); err == nil || policy != nil {
+			t.Errorf("NewCORSPolicy(%+v) = %v, %v, want an error", config, policy, err)
+		}
+	}
+}
+
+func TestEnableCORSDoesNotAllowCredentials(t *testing.T) {
+	router := newTestRouter(okHandler, EnableCORS())
+	w := serve(router, http.MethodGet, "/test", "", "Origin: https://evil.com")
+	if w.Header().Get("Access-Control-Allow-Origin") != "*" || w.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Errorf("headers = %v, want * without credentials", w.Header())
+	}
+}
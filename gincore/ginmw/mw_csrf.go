@@ -0,0 +1,179 @@
+package ginmw
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nk-bm/gocore/gincore/response"
+)
+
+// csrfTokenKey stores the token of the request in the gin context
+const csrfTokenKey = "gocore.csrf_token"
+
+// CSRFConfig configures double-submit cookie protection: the token of a readable cookie must be sent back
+// in a header or form field, which other sites cannot do
+type CSRFConfig struct {
+	CookieName string `env:"GIN_CSRF_COOKIE_NAME; default:csrf_token"`
+	HeaderName string `env:"GIN_CSRF_HEADER_NAME; default:X-CSRF-Token"`
+	// FormField is checked for form posts without the header, empty disables it
+	FormField    string        `env:"GIN_CSRF_FORM_FIELD; default:csrf_token"`
+	CookiePath   string        `env:"GIN_CSRF_COOKIE_PATH; default:/"`
+	CookieDomain string        `env:"GIN_CSRF_COOKIE_DOMAIN"`
+	CookieSecure bool          `env:"GIN_CSRF_COOKIE_SECURE; default:true"`
+	SameSite     string        `env:"GIN_CSRF_SAME_SITE; default:lax"`
+	TTL          time.Duration `env:"GIN_CSRF_TTL; default:12h"`
+	// Secret signs tokens, so that a cookie planted by a sibling subdomain is rejected
+	Secret string `env:"GIN_CSRF_SECRET; secret"`
+	// SessionCookies are the cookies that authenticate requests. Unsafe requests without them are not
+	// checked, since browsers send only cookies on their own and a header cannot be forged by other sites,
+	// so Bearer and TMA token requests pass alongside analytics or locale cookies.
+	// Empty treats every cookie other than the token cookie as a session cookie, EnableCSRF of gincore
+	// requires the list
+	SessionCookies []string `env:"GIN_CSRF_SESSION_COOKIES"`
+	// Skip exempts requests from the check and from the token cookie
+	Skip func(c *gin.Context) bool
+}
+
+func (c CSRFConfig) Validate() error {
+	if _, err := parseSameSite(c.SameSite); err != nil {
+		return err
+	}
+	return nil
+}
+
+func parseSameSite(value string) (http.SameSite, error) {
+	switch strings.ToLower(value) {
+	case "", "lax":
+		return http.SameSiteLaxMode, nil
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	default:
+		return 0, fmt.Errorf("invalid GIN_CSRF_SAME_SITE %q: must be lax, strict or none", value)
+	}
+}
+
+// hasSessionCookie reports whether the browser could have authenticated the request on its own.
+// Other headers are not considered: they may be sent by any site without ever being validated
+func (c CSRFConfig) hasSessionCookie(r *http.Request) bool {
+	for _, cookie := range r.Cookies() {
+		if len(c.SessionCookies) == 0 {
+			if cookie.Name != c.CookieName {
+				return true
+			}
+		} else if slices.Contains(c.SessionCookies, cookie.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// CSRFMW issues the token cookie on any request without a valid one and answers 403 to unsafe requests
+// (other than GET, HEAD, OPTIONS and TRACE) with a session cookie whose header or form field does not
+// match the token cookie. The token of the request is returned by CSRFToken
+func CSRFMW(config CSRFConfig) gin.HandlerFunc {
+	sameSite, _ := parseSameSite(config.SameSite)
+	tokens := csrfTokens{secret: []byte(config.Secret)}
+
+	return func(c *gin.Context) {
+		if config.Skip != nil && config.Skip(c) {
+			c.Next()
+			return
+		}
+
+		cookie, _ := c.Cookie(config.CookieName)
+		if !tokens.valid(cookie) {
+			cookie = ""
+		}
+
+		if !safeMethod(c.Request.Method) && config.hasSessionCookie(c.Request) {
+			sent := c.GetHeader(config.HeaderName)
+			if sent == "" && config.FormField != "" {
+				sent = c.PostForm(config.FormField)
+			}
+			if cookie == "" || subtle.ConstantTimeCompare([]byte(sent), []byte(cookie)) != 1 {
+				response.ErrorString(c, "CSRF token mismatch", http.StatusForbidden)
+				c.Abort()
+				return
+			}
+		}
+
+		if cookie == "" {
+			var err error
+			if cookie, err = tokens.generate(); err != nil {
+				response.InternalServerError(c)
+				c.Abort()
+				return
+			}
+			// The cookie is readable by scripts, which send it back in the header
+			http.SetCookie(c.Writer, &http.Cookie{
+				Name:     config.CookieName,
+				Value:    cookie,
+				Path:     config.CookiePath,
+				Domain:   config.CookieDomain,
+				MaxAge:   int(config.TTL / time.Second),
+				Secure:   config.CookieSecure,
+				HttpOnly: false,
+				SameSite: sameSite,
+			})
+		}
+		c.Set(csrfTokenKey, cookie)
+		c.Next()
+	}
+}
+
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// CSRFToken returns the token to embed in forms or pages, empty when CSRFMW did not run or skipped the request
+func CSRFToken(c *gin.Context) string {
+	return c.GetString(csrfTokenKey)
+}
+
+type csrfTokens struct {
+	secret []byte
+}
+
+func (t csrfTokens) generate() (string, error) {
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(nonce)
+	if len(t.secret) > 0 {
+		token += "." + t.sign(token)
+	}
+	return token, nil
+}
+
+func (t csrfTokens) valid(token string) bool {
+	if token == "" {
+		return false
+	}
+	if len(t.secret) == 0 {
+		return true
+	}
+	nonce, signature, ok := strings.Cut(token, ".")
+	return ok && hmac.Equal([]byte(signature), []byte(t.sign(nonce)))
+}
+
+func (t csrfTokens) sign(nonce string) string {
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte(nonce))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,123 @@
+package ginmw
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func testCSRFConfig() CSRFConfig {
+	return CSRFConfig{
+		CookieName: "csrf_token",
+		HeaderName: "X-CSRF-Token",
+		FormField:  "csrf_token",
+		CookiePath: "/",
+		SameSite:   "lax",
+		TTL:        time.Hour,
+		Secret:     "secret",
+	}
+}
+
+// issueCSRFToken returns the token cookie issued to a GET request
+func issueCSRFToken(t *testing.T, router *gin.Engine) string {
+	t.Helper()
+	w := serve(router, http.MethodGet, "/test", "")
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == "csrf_token" {
+			return cookie.Value
+		}
+	}
+	t.Fatalf("GET did not issue the token cookie: %v", w.Header())
+	return ""
+}
+
+func TestCSRFMW(t *testing.T) {
+	var issued string
+	router := newTestRouter(func(c *gin.Context) {
+		issued = CSRFToken(c)
+		c.Status(http.StatusOK)
+	}, CSRFMW(testCSRFConfig()))
+	token := issueCSRFToken(t, router)
+	if issued != token {
+		t.Errorf("CSRFToken = %q, want the issued cookie %q", issued, token)
+	}
+	cookies := "Cookie: session=s1; csrf_token=" + token
+
+	tests := []struct {
+		name    string
+		headers []string
+		want    int
+	}{
+		{"session without the token", []string{cookies}, http.StatusForbidden},
+		{"session with a wrong token", []string{cookies, "X-CSRF-Token: other"}, http.StatusForbidden},
+		// A header that is never validated must not disable the check for cookie sessions
+		{"session with a junk bearer token", []string{cookies, "Authorization: Bearer junk"}, http.StatusForbidden},
+		{"session with a junk TMA token", []string{cookies, "X-TMA-Token: junk"}, http.StatusForbidden},
+		{"session with the token", []string{cookies, "X-CSRF-Token: " + token}, http.StatusOK},
+		{"session without the token cookie", []string{"Cookie: session=s1", "X-CSRF-Token: " + token}, http.StatusForbidden},
+		{"header authentication without cookies", []string{"Authorization: Bearer token"}, http.StatusOK},
+		{"only the token cookie", []string{"Cookie: csrf_token=" + token}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		if w := serve(router, http.MethodPost, "/test", "", tt.headers...); w.Code != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, w.Code, tt.want)
+		}
+	}
+}
+
+func TestCSRFMWFormField(t *testing.T) {
+	router := newTestRouter(okHandler, CSRFMW(testCSRFConfig()))
+	token := issueCSRFToken(t, router)
+
+	form := url.Values{"csrf_token": {token}}.Encode()
+	req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(form))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Cookie", "session=s1; csrf_token="+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("form post with the token: status %d, want 200", w.Code)
+	}
+}
+
+func TestCSRFMWRejectsUnsignedTokens(t *testing.T) {
+	router := newTestRouter(okHandler, CSRFMW(testCSRFConfig()))
+
+	// A sibling subdomain may plant a cookie, but cannot sign it
+	w := serve(router, http.MethodPost, "/test", "", "Cookie: session=s1; csrf_token=planted", "X-CSRF-Token: planted")
+	if w.Code != http.StatusForbidden {
+		t.Errorf("planted token: status %d, want 403", w.Code)
+	}
+}
+
+func TestCSRFMWSessionCookies(t *testing.T) {
+	config := testCSRFConfig()
+	config.SessionCookies = []string{"session"}
+	router := newTestRouter(okHandler, CSRFMW(config))
+
+	if w := serve(router, http.MethodPost, "/test", "", "Cookie: theme=dark"); w.Code != http.StatusOK {
+		t.Errorf("other cookies: status %d, want 200", w.Code)
+	}
+	if w := serve(router, http.MethodPost, "/test", "", "Cookie: theme=dark; _ga=1", "Authorization: Bearer token"); w.Code != http.StatusOK {
+		t.Errorf("bearer token with other cookies: status %d, want 200", w.Code)
+	}
+	if w := serve(router, http.MethodPost, "/test", "", "Cookie: theme=dark; session=s1"); w.Code != http.StatusForbidden {
+		t.Errorf("session cookie: status %d, want 403", w.Code)
+	}
+}
+
+func TestCSRFMWSkip(t *testing.T) {
+	config := testCSRFConfig()
+	config.Skip = func(c *gin.Context) bool { return c.Request.URL.Query().Has("webhook") }
+	router := newTestRouter(okHandler, CSRFMW(config))
+
+	w := serve(router, http.MethodPost, "/test?webhook", "", "Cookie: session=s1")
+	if w.Code != http.StatusOK || len(w.Result().Cookies()) != 0 {
+		t.Errorf("skipped request: status %d, cookies %v", w.Code, w.Result().Cookies())
+	}
+}
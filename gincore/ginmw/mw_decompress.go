@@ -0,0 +1,57 @@
+package ginmw
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nk-bm/gocore/gincore/response"
+)
+
+// DecompressMW transparently decompresses request bodies with "Content-Encoding: gzip" and answers 415
+// to other encodings. It must run before BodyLimitMW, so that the limit applies to the decompressed body
+func DecompressMW() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		encoding := strings.ToLower(strings.TrimSpace(c.GetHeader("Content-Encoding")))
+		switch encoding {
+		case "", "identity":
+			c.Next()
+			return
+		case "gzip", "x-gzip":
+		default:
+			response.ErrorString(c, "Unsupported Content-Encoding "+encoding, http.StatusUnsupportedMediaType)
+			c.Abort()
+			return
+		}
+
+		if c.Request.Body == nil || c.Request.Body == http.NoBody || c.Request.ContentLength == 0 {
+			c.Request.Header.Del("Content-Encoding")
+			c.Next()
+			return
+		}
+
+		reader, err := gzip.NewReader(c.Request.Body)
+		if err != nil {
+			response.BadRequestWithMessage(c, "invalid gzip body: "+err.Error())
+			c.Abort()
+			return
+		}
+		c.Request.Body = gzipBody{Reader: reader, body: c.Request.Body}
+		c.Request.Header.Del("Content-Encoding")
+		c.Request.Header.Del("Content-Length")
+		c.Request.ContentLength = -1
+		c.Next()
+	}
+}
+
+type gzipBody struct {
+	*gzip.Reader
+	body io.Closer
+}
+
+func (b gzipBody) Close() error {
+	b.Reader.Close()
+	return b.body.Close()
+}
@@ -0,0 +1,56 @@
+package ginmw
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func gzipString(s string) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write([]byte(s))
+	w.Close()
+	return buf.Bytes()
+}
+
+func serveEncoded(handler http.Handler, encoding string, body []byte) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/test", bytes.NewReader(body))
+	req.Header.Set("Content-Encoding", encoding)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestDecompressMW(t *testing.T) {
+	router := newTestRouter(readBodyHandler, DecompressMW())
+
+	body := bytes.Repeat([]byte("a"), 1000)
+	if w := serveEncoded(router, "gzip", gzipString(string(body))); w.Code != http.StatusOK || w.Body.String() != "1000" {
+		t.Errorf("gzip body: status %d, body %s, want the decompressed 1000 bytes", w.Code, w.Body.String())
+	}
+	if w := serveEncoded(router, "identity", body); w.Code != http.StatusOK || w.Body.String() != "1000" {
+		t.Errorf("identity body: status %d, body %s", w.Code, w.Body.String())
+	}
+	if w := serveEncoded(router, "deflate", body); w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("deflate body: status %d, want 415", w.Code)
+	}
+	if w := serveEncoded(router, "gzip", body); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid gzip body: status %d, want 400", w.Code)
+	}
+}
+
+func TestDecompressMWLimitsDecompressedSize(t *testing.T) {
+	router := newTestRouter(readBodyHandler, DecompressMW(), BodyLimitMW(100))
+
+	// A small gzip body expanding beyond the limit is rejected while it is read
+	compressed := gzipString(string(bytes.Repeat([]byte("a"), 10000)))
+	if len(compressed) > 100 {
+		t.Fatalf("compressed body is %d bytes, want it under the limit", len(compressed))
+	}
+	if w := serveEncoded(router, "gzip", compressed); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status %d, want 413", w.Code)
+	}
+}
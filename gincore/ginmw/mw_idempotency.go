@@ -0,0 +1,260 @@
+package ginmw
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nk-bm/gocore/gincore/response"
+	"github.com/nk-bm/gocore/gostore"
+	"go.uber.org/zap"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marks responses replayed from the store
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// Error types of idempotency responses
+const (
+	ErrorTypeIdempotencyInProgress          = "idempotency_in_progress"
+	ErrorTypeIdempotencyFingerprintMismatch = "idempotency_fingerprint_mismatch"
+	ErrorTypeIdempotencyUnauthenticated     = "idempotency_unauthenticated"
+)
+
+// idempotencyReplayHeaders are stored with the response and replayed
+var idempotencyReplayHeaders = []string{"Content-Type", "Location", "ETag"}
+
+type IdempotencyConfig struct {
+	// TTL is how long responses are replayed
+	TTL time.Duration `env:"GIN_IDEMPOTENCY_TTL; default:24h"`
+	// LockTimeout is how long a request in flight blocks duplicates, after it the request is considered lost
+	LockTimeout time.Duration `env:"GIN_IDEMPOTENCY_LOCK_TIMEOUT; default:1m"`
+	// Store is memory or postgres. The postgres store is shared between replicas
+	Store     string `env:"GIN_IDEMPOTENCY_STORE; default:memory"`
+	UserIDKey string `env:"GIN_IDEMPOTENCY_USER_ID_KEY; default:user_id"`
+	// Required answers 400 to unsafe requests without the header
+	Required     bool `env:"GIN_IDEMPOTENCY_REQUIRED; default:false"`
+	MaxKeyLength int  `env:"GIN_IDEMPOTENCY_MAX_KEY_LENGTH; default:255"`
+	// MaxBodySize bounds the request bodies buffered for the fingerprint, larger requests with a key get 413,
+	// and the stored responses, larger responses are not stored and can be retried
+	MaxBodySize int64 `env:"GIN_IDEMPOTENCY_MAX_BODY_SIZE; default:1048576"`
+}
+
+func (c IdempotencyConfig) Validate() error {
+	switch c.Store {
+	case "memory", "postgres":
+	default:
+		return fmt.Errorf("unknown idempotency store %q", c.Store)
+	}
+	if c.TTL <= 0 || c.LockTimeout <= 0 || c.MaxBodySize <= 0 {
+		return fmt.Errorf("GIN_IDEMPOTENCY_TTL, GIN_IDEMPOTENCY_LOCK_TIMEOUT and GIN_IDEMPOTENCY_MAX_BODY_SIZE must be positive")
+	}
+	return nil
+}
+
+type IdempotencyOptions struct {
+	Config IdempotencyConfig
+	Store  gostore.IdempotencyStore
+	// Scope returns the user the key belongs to. Requests with a key and without a user are answered 401,
+	// so authentication must run before the middleware
+	Scope  func(c *gin.Context) (string, bool)
+	Logger *zap.Logger
+}
+
+// IdempotencyMW makes POST, PUT, PATCH and DELETE requests with an Idempotency-Key header safe to retry.
+// The first response of a key and user is stored and replayed to retries, a retry while the first request
+// is in flight gets 409 and a key reused with a different method, path or body gets 422.
+// Keys belong to the user set by authentication, which must run before the middleware.
+// Server errors, requests that time out and responses over MaxBodySize are not stored, so that the request can be retried
+func IdempotencyMW(opts IdempotencyOptions) gin.HandlerFunc {
+	scope := opts.Scope
+	if scope == nil {
+		scope = KeyFirst(KeyByUserID(opts.Config.UserIDKey), KeyByTMAUser)
+	}
+
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			c.Next()
+			return
+		}
+
+		idempotencyKey := c.GetHeader(IdempotencyKeyHeader)
+		if idempotencyKey == "" {
+			if opts.Config.Required {
+				response.BadRequestWithMessage(c, IdempotencyKeyHeader+" header is required")
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+		if opts.Config.MaxKeyLength > 0 && len(idempotencyKey) > opts.Config.MaxKeyLength {
+			response.BadRequestWithMessage(c, fmt.Sprintf("%s is longer than %d characters", IdempotencyKeyHeader, opts.Config.MaxKeyLength))
+			c.Abort()
+			return
+		}
+
+		// Keys of different users must not share responses
+		user, ok := scope(c)
+		if !ok {
+			response.NewResponse().
+				SetErrorString(IdempotencyKeyHeader+" requires an authenticated user", http.StatusUnauthorized).
+				SetErrorType(ErrorTypeIdempotencyUnauthenticated).
+				Respond(c)
+			c.Abort()
+			return
+		}
+		fingerprint, err := requestFingerprint(c, opts.Config.MaxBodySize)
+		if err != nil {
+			if errors.Is(err, errIdempotentBodyTooLarge) || errors.As(err, new(*http.MaxBytesError)) {
+				response.PayloadTooLarge(c)
+			} else {
+				response.BadRequestWithMessage(c, "failed to read request body: "+err.Error())
+			}
+			c.Abort()
+			return
+		}
+		key := user + ":" + idempotencyKey
+		logger := ctxLoggerOr(c, opts.Logger).With(zap.String("idempotency_key", idempotencyKey))
+
+		record, claimed, err := opts.Store.Begin(c.Request.Context(), key, fingerprint, opts.Config.LockTimeout, opts.Config.TTL)
+		if err != nil {
+			logger.Error("Idempotency store failed", zap.Error(err))
+			response.ServiceUnavailable(c)
+			c.Abort()
+			return
+		}
+		if !claimed {
+			switch {
+			case record.Fingerprint != fingerprint:
+				response.NewResponse().
+					SetErrorString(IdempotencyKeyHeader+" was used with a different request", http.StatusUnprocessableEntity).
+					SetErrorType(ErrorTypeIdempotencyFingerprintMismatch).
+					Respond(c)
+			case record.Status == 0:
+				response.NewResponse().
+					SetErrorString("A request with this "+IdempotencyKeyHeader+" is in progress", http.StatusConflict).
+					SetErrorType(ErrorTypeIdempotencyInProgress).
+					Respond(c)
+			default:
+				replayResponse(c, record)
+			}
+			c.Abort()
+			return
+		}
+
+		// Recording continues when the client disconnects
+		storeCtx := context.WithoutCancel(c.Request.Context())
+		released := false
+		defer func() {
+			if r := recover(); r != nil {
+				if !released {
+					_ = opts.Store.Release(storeCtx, key, fingerprint)
+				}
+				panic(r)
+			}
+		}()
+
+		writer := &recordingWriter{ResponseWriter: c.Writer, limit: opts.Config.MaxBodySize}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		// A request that timed out without a response is answered 503 by TimeoutMW after this middleware
+		status := writer.Status()
+		if writer.overflow {
+			logger.Warn("Response is too large to store for idempotency", zap.Int64("max_body_size", opts.Config.MaxBodySize))
+		}
+		if status >= http.StatusInternalServerError || !writer.Written() || c.Request.Context().Err() != nil || writer.overflow {
+			released = true
+			if err := opts.Store.Release(storeCtx, key, fingerprint); err != nil {
+				logger.Error("Failed to release idempotency key", zap.Error(err))
+			}
+			return
+		}
+
+		header := make(http.Header)
+		for _, name := range idempotencyReplayHeaders {
+			if value := writer.Header().Get(name); value != "" {
+				header.Set(name, value)
+			}
+		}
+		completed := gostore.IdempotencyRecord{Fingerprint: fingerprint, Status: status, Header: header, Body: writer.body.Bytes()}
+		if err := opts.Store.Complete(storeCtx, key, fingerprint, completed, opts.Config.TTL); err != nil {
+			logger.Error("Failed to store idempotent response", zap.Error(err))
+		}
+	}
+}
+
+var errIdempotentBodyTooLarge = errors.New("request body is too large")
+
+// requestFingerprint hashes the method, path, query and body of up to limit bytes,
+// the body is restored for the handler
+func requestFingerprint(c *gin.Context, limit int64) (string, error) {
+	hash := sha256.New()
+	io.WriteString(hash, c.Request.Method+" "+c.Request.URL.RequestURI()+"\n")
+	if c.Request.Body != nil && c.Request.Body != http.NoBody {
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, limit+1))
+		c.Request.Body.Close()
+		if err != nil {
+			return "", err
+		}
+		if int64(len(body)) > limit {
+			return "", errIdempotentBodyTooLarge
+		}
+		hash.Write(body)
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func replayResponse(c *gin.Context, record *gostore.IdempotencyRecord) {
+	header := c.Writer.Header()
+	for name, values := range record.Header {
+		header[name] = values
+	}
+	header.Set(IdempotentReplayedHeader, "true")
+	c.Status(record.Status)
+	c.Writer.Write(record.Body)
+}
+
+// recordingWriter keeps a copy of the response body of up to limit bytes, overflow is set when it is larger
+type recordingWriter struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	limit    int64
+	overflow bool
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	w.record(p)
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.record([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *recordingWriter) record(p []byte) {
+	if w.overflow {
+		return
+	}
+	if int64(w.body.Len()+len(p)) > w.limit {
+		w.overflow = true
+		w.body = bytes.Buffer{}
+		return
+	}
+	w.body.Write(p)
+}
@@ -0,0 +1,189 @@
+package ginmw
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nk-bm/gocore/gostore"
+	"go.uber.org/zap"
+)
+
+type failingIdempotencyStore struct {
+	gostore.IdempotencyStore
+}
+
+func (failingIdempotencyStore) Begin(context.Context, string, string, time.Duration, time.Duration) (*gostore.IdempotencyRecord, bool, error) {
+	return nil, false, errors.New("store is down")
+}
+
+func testIdempotencyOptions(store gostore.IdempotencyStore) IdempotencyOptions {
+	return IdempotencyOptions{
+		Config: IdempotencyConfig{TTL: time.Hour, LockTimeout: time.Minute, UserIDKey: "user_id", MaxKeyLength: 16, MaxBodySize: 64},
+		Store:  store,
+		Logger: zap.NewNop(),
+	}
+}
+
+// testUserMW authenticates the user of the X-User header
+func testUserMW(c *gin.Context) {
+	if user := c.GetHeader("X-User"); user != "" {
+		c.Set("user_id", user)
+	}
+	c.Next()
+}
+
+// countingHandler answers 201 with the number of calls
+func countingHandler(calls *int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		*calls++
+		c.Header("Location", fmt.Sprintf("/orders/%d", *calls))
+		c.String(http.StatusCreated, "order %d", *calls)
+	}
+}
+
+func TestIdempotencyMWReplays(t *testing.T) {
+	var calls int
+	router := newTestRouter(countingHandler(&calls), testUserMW, IdempotencyMW(testIdempotencyOptions(gostore.NewMemoryIdempotencyStore())))
+
+	first := serve(router, http.MethodPost, "/test", `{"amount":1}`, "X-User: alice", "Idempotency-Key: k1")
+	retry := serve(router, http.MethodPost, "/test", `{"amount":1}`, "X-User: alice", "Idempotency-Key: k1")
+	if calls != 1 {
+		t.Fatalf("handler ran %d times, want once", calls)
+	}
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() ||
+		retry.Header().Get("Location") != "/orders/1" || retry.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Errorf("retry = %d %s %v, want the replayed first response", retry.Code, retry.Body.String(), retry.Header())
+	}
+
+	if w := serve(router, http.MethodPost, "/test", `{"amount":2}`, "X-User: alice", "Idempotency-Key: k1"); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("key reused with another body: status %d, want 422", w.Code)
+	}
+	serve(router, http.MethodPost, "/test", `{"amount":1}`, "X-User: alice")
+	serve(router, http.MethodGet, "/test", "", "X-User: alice", "Idempotency-Key: k1")
+	if calls != 3 {
+		t.Errorf("handler ran %d times, want requests without a key and safe requests to run", calls)
+	}
+}
+
+func TestIdempotencyMWScopesKeysByUser(t *testing.T) {
+	var calls int
+	router := newTestRouter(countingHandler(&calls), testUserMW, IdempotencyMW(testIdempotencyOptions(gostore.NewMemoryIdempotencyStore())))
+
+	alice := serve(router, http.MethodPost, "/test", "{}", "X-User: alice", "Idempotency-Key: k1")
+	bob := serve(router, http.MethodPost, "/test", "{}", "X-User: bob", "Idempotency-Key: k1")
+	if calls != 2 || alice.Body.String() == bob.Body.String() {
+		t.Errorf("users sharing a key: %d calls, responses %q and %q", calls, alice.Body.String(), bob.Body.String())
+	}
+
+	// Without a user the key cannot be scoped
+	w := serve(router, http.MethodPost, "/test", "{}", "Idempotency-Key: k1")
+	if w.Code != http.StatusUnauthorized || calls != 2 {
+		t.Errorf("key without a user: status %d, %d calls, want 401 before the handler", w.Code, calls)
+	}
+}
+
+func newIdempotencyTestRequest(body string) *http.Request {
+	return httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(body))
+}
+
+func TestIdempotencyMWInProgress(t *testing.T) {
+	store := gostore.NewMemoryIdempotencyStore()
+	router := newTestRouter(okHandler, testUserMW, IdempotencyMW(testIdempotencyOptions(store)))
+	fingerprint, _ := requestFingerprint(&gin.Context{Request: newIdempotencyTestRequest(`{}`)}, 64)
+	store.Begin(context.Background(), "user:alice:k1", fingerprint, time.Minute, time.Hour)
+
+	if w := serve(router, http.MethodPost, "/test", "{}", "X-User: alice", "Idempotency-Key: k1"); w.Code != http.StatusConflict {
+		t.Errorf("retry while in flight: status %d, want 409", w.Code)
+	}
+}
+
+func TestIdempotencyMWDoesNotStoreFailures(t *testing.T) {
+	var calls int
+	failing := func(c *gin.Context) {
+		calls++
+		c.String(http.StatusBadGateway, "upstream failed")
+	}
+	router := newTestRouter(failing, testUserMW, IdempotencyMW(testIdempotencyOptions(gostore.NewMemoryIdempotencyStore())))
+
+	serve(router, http.MethodPost, "/test", "{}", "X-User: alice", "Idempotency-Key: k1")
+	serve(router, http.MethodPost, "/test", "{}", "X-User: alice", "Idempotency-Key: k1")
+	if calls != 2 {
+		t.Errorf("handler ran %d times, want server errors retried", calls)
+	}
+}
+
+func TestIdempotencyMWDoesNotStoreTimeouts(t *testing.T) {
+	var calls int
+	slow := func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			<-c.Request.Context().Done()
+			return
+		}
+		c.String(http.StatusCreated, "paid")
+	}
+	router := newTestRouter(slow, TimeoutMW(10*time.Millisecond), testUserMW, IdempotencyMW(testIdempotencyOptions(gostore.NewMemoryIdempotencyStore())))
+
+	if w := serve(router, http.MethodPost, "/test", "{}", "X-User: alice", "Idempotency-Key: k1"); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("timed out request: status %d, want 503", w.Code)
+	}
+	// The retry runs the handler instead of replaying an empty 200
+	w := serve(router, http.MethodPost, "/test", "{}", "X-User: alice", "Idempotency-Key: k1")
+	if w.Code != http.StatusCreated || calls != 2 {
+		t.Errorf("retry after a timeout: status %d, %d calls, want 201 from the handler", w.Code, calls)
+	}
+}
+
+func TestIdempotencyMWRejectsInvalidRequests(t *testing.T) {
+	opts := testIdempotencyOptions(gostore.NewMemoryIdempotencyStore())
+	opts.Config.Required = true
+	router := newTestRouter(okHandler, testUserMW, IdempotencyMW(opts))
+
+	if w := serve(router, http.MethodPost, "/test", "{}", "X-User: alice"); w.Code != http.StatusBadRequest {
+		t.Errorf("missing required key: status %d, want 400", w.Code)
+	}
+	if w := serve(router, http.MethodPost, "/test", "{}", "X-User: alice", "Idempotency-Key: 0123456789abcdefg"); w.Code != http.StatusBadRequest {
+		t.Errorf("long key: status %d, want 400", w.Code)
+	}
+
+	router = newTestRouter(okHandler, testUserMW, IdempotencyMW(testIdempotencyOptions(failingIdempotencyStore{})))
+	if w := serve(router, http.MethodPost, "/test", "{}", "X-User: alice", "Idempotency-Key: k1"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("store failure: status %d, want 503", w.Code)
+	}
+}
+
+func TestIdempotencyMWLimitsBodies(t *testing.T) {
+	var calls int
+	handler := func(c *gin.Context) {
+		calls++
+		c.String(http.StatusCreated, "%s", c.Query("reply"))
+	}
+	router := newTestRouter(handler, testUserMW, IdempotencyMW(testIdempotencyOptions(gostore.NewMemoryIdempotencyStore())))
+
+	large := strings.Repeat("x", 65)
+	if w := serve(router, http.MethodPost, "/test", large, "X-User: alice", "Idempotency-Key: k1"); w.Code != http.StatusRequestEntityTooLarge || calls != 0 {
+		t.Errorf("large request: status %d, %d calls, want 413 before the handler", w.Code, calls)
+	}
+	if w := serve(router, http.MethodPost, "/test", large, "X-User: alice"); w.Code != http.StatusCreated {
+		t.Errorf("large request without a key: status %d, want 201", w.Code)
+	}
+
+	// A response larger than the limit is not stored, so the retry runs the handler
+	calls = 0
+	for range 2 {
+		w := serve(router, http.MethodPost, "/test?reply="+large, "{}", "X-User: alice", "Idempotency-Key: k2")
+		if w.Code != http.StatusCreated || w.Body.String() != large {
+			t.Fatalf("large response: status %d, body %q", w.Code, w.Body.String())
+		}
+	}
+	if calls != 2 {
+		t.Errorf("handler ran %d times, want the large response not replayed", calls)
+	}
+}
@@ -0,0 +1,60 @@
+package ginmw
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nk-bm/gocore/gincore/response"
+)
+
+// ParseIPAllowList parses IP addresses and CIDR ranges like "10.0.0.0/8"
+func ParseIPAllowList(entries []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR %q: %w", entry, err)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IP %q: %w", entry, err)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// IPAllowListMW allows requests from the given networks. The address of the connection is used,
+// forwarding headers are ignored because clients can set them
+func IPAllowListMW(allowed []netip.Prefix) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		addr, err := netip.ParseAddr(c.RemoteIP())
+		if err != nil || !ipAllowed(allowed, addr.Unmap()) {
+			response.Forbidden(c)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func ipAllowed(allowed []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range allowed {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,243 @@
+package ginmw
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nk-bm/gocore/gincore/response"
+	"github.com/nk-bm/gocore/gincore/static"
+	"github.com/nk-bm/gocore/gostore"
+	"github.com/nk-bm/gocore/gotypes"
+	"go.uber.org/zap"
+)
+
+type RateLimitConfig struct {
+	Algorithm string        `env:"GIN_RATE_LIMIT_ALGORITHM; default:token_bucket"`
+	Limit     int           `env:"GIN_RATE_LIMIT_LIMIT; default:100"`
+	Window    time.Duration `env:"GIN_RATE_LIMIT_WINDOW; default:1m"`
+	// Burst is the token bucket capacity, Limit if zero
+	Burst int `env:"GIN_RATE_LIMIT_BURST; default:0"`
+	// Key is ip, user, tma_user or auto: the first of user, tma_user and ip available for the request
+	Key       string `env:"GIN_RATE_LIMIT_KEY; default:auto"`
+	UserIDKey string `env:"GIN_RATE_LIMIT_USER_ID_KEY; default:user_id"`
+	// Store is memory or postgres. The postgres store shares limits between replicas
+	Store string `env:"GIN_RATE_LIMIT_STORE; default:memory"`
+	// Classes are limits selected by RouteMeta.RateLimitClass, as "name=limit/window[/burst]"
+	Classes []string `env:"GIN_RATE_LIMIT_CLASSES"`
+	// FailOpen lets requests through when the store fails
+	FailOpen bool `env:"GIN_RATE_LIMIT_FAIL_OPEN; default:true"`
+}
+
+func (c RateLimitConfig) Validate() error {
+	if _, err := c.Limits(); err != nil {
+		return err
+	}
+	if _, err := c.KeyFunc(); err != nil {
+		return err
+	}
+	switch c.Store {
+	case "memory", "postgres":
+	default:
+		return fmt.Errorf("unknown rate limit store %q", c.Store)
+	}
+	return nil
+}
+
+// Limits returns the default limit under the empty name and the limits of Classes
+func (c RateLimitConfig) Limits() (map[string]gostore.RateLimit, error) {
+	base := gostore.RateLimit{Algorithm: c.Algorithm, Limit: c.Limit, Window: c.Window, Burst: c.Burst}
+	if err := base.Validate(); err != nil {
+		return nil, err
+	}
+	limits := map[string]gostore.RateLimit{"": base}
+
+	for _, class := range c.Classes {
+		name, spec, ok := strings.Cut(class, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid rate limit class %q, expected name=limit/window[/burst]", class)
+		}
+		parts := strings.Split(spec, "/")
+		if len(parts) < 2 || len(parts) > 3 {
+			return nil, fmt.Errorf("invalid rate limit class %q, expected name=limit/window[/burst]", class)
+		}
+
+		limit := gostore.RateLimit{Algorithm: c.Algorithm}
+		var err error
+		if limit.Limit, err = strconv.Atoi(parts[0]); err != nil {
+			return nil, fmt.Errorf("invalid limit of rate limit class %q: %w", name, err)
+		}
+		if limit.Window, err = time.ParseDuration(parts[1]); err != nil {
+			return nil, fmt.Errorf("invalid window of rate limit class %q: %w", name, err)
+		}
+		if len(parts) == 3 {
+			if limit.Burst, err = strconv.Atoi(parts[2]); err != nil {
+				return nil, fmt.Errorf("invalid burst of rate limit class %q: %w", name, err)
+			}
+		}
+		if err := limit.Validate(); err != nil {
+			return nil, fmt.Errorf("rate limit class %q: %w", name, err)
+		}
+		limits[name] = limit
+	}
+	return limits, nil
+}
+
+// KeyFunc returns the key function selected by Key
+func (c RateLimitConfig) KeyFunc() (RateLimitKeyFunc, error) {
+	switch c.Key {
+	case "ip":
+		return KeyByIP, nil
+	case "user":
+		return KeyByUserID(c.UserIDKey), nil
+	case "tma_user":
+		return KeyByTMAUser, nil
+	case "auto", "":
+		return KeyFirst(KeyByUserID(c.UserIDKey), KeyByTMAUser, KeyByIP), nil
+	}
+	return nil, fmt.Errorf("unknown rate limit key %q", c.Key)
+}
+
+// RateLimitKeyFunc returns the key requests are counted under, false if the request has none
+type RateLimitKeyFunc func(c *gin.Context) (string, bool)
+
+func KeyByIP(c *gin.Context) (string, bool) {
+	return "ip:" + c.ClientIP(), true
+}
+
+// KeyByUserID counts requests by the ID set by AuthMW under idKey
+func KeyByUserID(idKey string) RateLimitKeyFunc {
+	return func(c *gin.Context) (string, bool) {
+		id, ok := c.Get(idKey)
+		if !ok {
+			return "", false
+		}
+		return fmt.Sprintf("user:%v", id), true
+	}
+}
+
+// KeyByTMAUser counts requests by the Telegram user validated by TelegramMiniAppAuthMW
+func KeyByTMAUser(c *gin.Context) (string, bool) {
+	switch initData := c.Value(static.TMA_INIT_DATA).(type) {
+	case gotypes.TelegramMiniAppInitData:
+		return "tma:" + strconv.FormatInt(initData.User.ID, 10), true
+	case *gotypes.TelegramMiniAppInitData:
+		return "tma:" + strconv.FormatInt(initData.User.ID, 10), true
+	}
+	return "", false
+}
+
+// KeyFirst uses the first key function that returns a key
+func KeyFirst(funcs ...RateLimitKeyFunc) RateLimitKeyFunc {
+	return func(c *gin.Context) (string, bool) {
+		for _, fn := range funcs {
+			if key, ok := fn(c); ok {
+				return key, true
+			}
+		}
+		return "", false
+	}
+}
+
+type RateLimitOptions struct {
+	// Name separates the counters of different limits for the same key
+	Name     string
+	Limit    gostore.RateLimit
+	Store    gostore.RateLimitStore
+	Key      RateLimitKeyFunc
+	FailOpen bool
+	Logger   *zap.Logger
+}
+
+// RateLimitMW answers 429 with Retry-After once the key of the request exceeds the limit.
+// Every counted response carries the RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and RateLimit-Policy headers
+func RateLimitMW(opts RateLimitOptions) gin.HandlerFunc {
+	policy := fmt.Sprintf("%d;w=%d", opts.Limit.Limit, int(opts.Limit.Window.Seconds()))
+	if opts.Limit.Algorithm == gostore.AlgorithmTokenBucket && opts.Limit.Burst > 0 {
+		policy += fmt.Sprintf(";burst=%d", opts.Limit.Burst)
+	}
+
+	return func(c *gin.Context) {
+		key, ok := opts.Key(c)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		result, err := opts.Store.Take(c.Request.Context(), opts.Name+":"+key, opts.Limit)
+		if err != nil {
+			ctxLoggerOr(c, opts.Logger).Error("Rate limit store failed", zap.String("limit", opts.Name), zap.Error(err))
+			if opts.FailOpen {
+				c.Next()
+				return
+			}
+			response.ErrorString(c, "Service Unavailable", http.StatusServiceUnavailable)
+			c.Abort()
+			return
+		}
+
+		header := c.Writer.Header()
+		header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		header.Set("RateLimit-Policy", policy)
+
+		if !result.Allowed {
+			header.Set("Retry-After", strconv.Itoa(max(1, ceilSeconds(result.RetryAfter))))
+			ctxLoggerOr(c, opts.Logger).Info("Rate limit exceeded", zap.String("limit", opts.Name), zap.String("key", key))
+			response.TooManyRequests(c)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RateLimitFailuresMW limits requests rejected with 401 or 403 by the handlers after it, per key.
+// It runs before authentication, so that credentials cannot be guessed without limit when the limit
+// of RateLimitMW is counted per user after authentication. Once the key exceeds the limit,
+// requests are answered 429 without reaching authentication
+func RateLimitFailuresMW(opts RateLimitOptions) gin.HandlerFunc {
+	name := opts.Name + ":failures"
+	return func(c *gin.Context) {
+		key, ok := opts.Key(c)
+		if !ok {
+			c.Next()
+			return
+		}
+		logger := ctxLoggerOr(c, opts.Logger)
+
+		result, err := opts.Store.Peek(c.Request.Context(), name+":"+key, opts.Limit)
+		if err != nil {
+			logger.Error("Rate limit store failed", zap.String("limit", name), zap.Error(err))
+			if !opts.FailOpen {
+				response.ErrorString(c, "Service Unavailable", http.StatusServiceUnavailable)
+				c.Abort()
+				return
+			}
+		} else if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(max(1, ceilSeconds(result.RetryAfter))))
+			logger.Info("Rate limit of failed requests exceeded", zap.String("limit", name), zap.String("key", key))
+			response.TooManyRequests(c)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+
+		switch c.Writer.Status() {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			if _, err := opts.Store.Take(c.Request.Context(), name+":"+key, opts.Limit); err != nil {
+				logger.Error("Rate limit store failed", zap.String("limit", name), zap.Error(err))
+			}
+		}
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,155 @@
+package ginmw
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nk-bm/gocore/gostore"
+	"go.uber.org/zap"
+)
+
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Take(context.Context, string, gostore.RateLimit) (gostore.RateLimitResult, error) {
+	return gostore.RateLimitResult{}, errors.New("store is down")
+}
+
+func (failingRateLimitStore) Peek(context.Context, string, gostore.RateLimit) (gostore.RateLimitResult, error) {
+	return gostore.RateLimitResult{}, errors.New("store is down")
+}
+
+func testRateLimitOptions(store gostore.RateLimitStore) RateLimitOptions {
+	return RateLimitOptions{
+		Name:   "default",
+		Limit:  gostore.RateLimit{Algorithm: gostore.AlgorithmTokenBucket, Limit: 2, Window: time.Minute},
+		Store:  store,
+		Key:    KeyByIP,
+		Logger: zap.NewNop(),
+	}
+}
+
+func TestRateLimitMW(t *testing.T) {
+	router := newTestRouter(okHandler, RateLimitMW(testRateLimitOptions(gostore.NewMemoryRateLimitStore())))
+
+	for i := range 2 {
+		w := serve(router, http.MethodGet, "/test", "")
+		if w.Code != http.StatusOK || w.Header().Get("RateLimit-Remaining") != []string{"1", "0"}[i] {
+			t.Fatalf("request %d: status %d, headers %v", i, w.Code, w.Header())
+		}
+		if w.Header().Get("RateLimit-Limit") != "2" || w.Header().Get("RateLimit-Policy") != "2;w=60" {
+			t.Errorf("request %d: headers %v", i, w.Header())
+		}
+	}
+
+	w := serve(router, http.MethodGet, "/test", "")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "30" {
+		t.Errorf("over the limit: status %d, Retry-After %q, want 429 after 30s", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	// Counters are separate per client
+	req := serve(router, http.MethodGet, "/test", "", "X-Forwarded-For: 203.0.113.7")
+	if req.Code != http.StatusOK {
+		t.Errorf("another client: status %d, want 200", req.Code)
+	}
+}
+
+func TestRateLimitMWWithoutKey(t *testing.T) {
+	opts := testRateLimitOptions(gostore.NewMemoryRateLimitStore())
+	opts.Key = KeyByUserID("user_id")
+	router := newTestRouter(okHandler, RateLimitMW(opts))
+
+	for range 5 {
+		if w := serve(router, http.MethodGet, "/test", ""); w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "" {
+			t.Fatalf("request without a user: status %d, headers %v", w.Code, w.Header())
+		}
+	}
+}
+
+func TestRateLimitMWStoreFailure(t *testing.T) {
+	opts := testRateLimitOptions(failingRateLimitStore{})
+	opts.FailOpen = true
+	if w := serve(newTestRouter(okHandler, RateLimitMW(opts)), http.MethodGet, "/test", ""); w.Code != http.StatusOK {
+		t.Errorf("fail open: status %d, want 200", w.Code)
+	}
+	opts.FailOpen = false
+	if w := serve(newTestRouter(okHandler, RateLimitMW(opts)), http.MethodGet, "/test", ""); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("fail closed: status %d, want 503", w.Code)
+	}
+}
+
+func TestRateLimitFailuresMW(t *testing.T) {
+	auth := func(c *gin.Context) {
+		if c.GetHeader("Authorization") != "Bearer valid" {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		c.Next()
+	}
+	router := newTestRouter(okHandler, RateLimitFailuresMW(testRateLimitOptions(gostore.NewMemoryRateLimitStore())), auth)
+
+	// Successful requests are not counted
+	for range 5 {
+		if w := serve(router, http.MethodGet, "/test", "", "Authorization: Bearer valid"); w.Code != http.StatusOK {
+			t.Fatalf("authenticated request: status %d, want 200", w.Code)
+		}
+	}
+	for range 2 {
+		if w := serve(router, http.MethodGet, "/test", "", "Authorization: Bearer guess"); w.Code != http.StatusUnauthorized {
+			t.Fatalf("failed request: status %d, want 401", w.Code)
+		}
+	}
+	w := serve(router, http.MethodGet, "/test", "", "Authorization: Bearer valid")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("request after failures: status %d, want 429 with Retry-After", w.Code)
+	}
+}
+
+func TestRateLimitConfigLimits(t *testing.T) {
+	config := RateLimitConfig{
+		Algorithm: gostore.AlgorithmSlidingWindow, Limit: 100, Window: time.Minute,
+		Classes: []string{"login=5/1m", "upload=10/1h/20"},
+	}
+	limits, err := config.Limits()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]gostore.RateLimit{
+		"":       {Algorithm: gostore.AlgorithmSlidingWindow, Limit: 100, Window: time.Minute},
+		"login":  {Algorithm: gostore.AlgorithmSlidingWindow, Limit: 5, Window: time.Minute},
+		"upload": {Algorithm: gostore.AlgorithmSlidingWindow, Limit: 10, Window: time.Hour, Burst: 20},
+	}
+	for name, limit := range want {
+		if limits[name] != limit {
+			t.Errorf("limit %q = %+v, want %+v", name, limits[name], limit)
+		}
+	}
+
+	for _, classes := range [][]string{{"login"}, {"=5/1m"}, {"login=5"}, {"login=x/1m"}, {"login=5/soon"}, {"login=0/1m"}} {
+		config.Classes = classes
+		if _, err := config.Limits(); err == nil {
+			t.Errorf("Limits() with classes %q succeeded, want an error", classes)
+		}
+	}
+}
+
+func TestRateLimitConfigValidate(t *testing.T) {
+	valid := RateLimitConfig{Algorithm: gostore.AlgorithmTokenBucket, Limit: 1, Window: time.Second, Key: "auto", Store: "memory"}
+	if err := valid.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for _, change := range []func(*RateLimitConfig){
+		func(c *RateLimitConfig) { c.Key = "session" },
+		func(c *RateLimitConfig) { c.Store = "redis" },
+		func(c *RateLimitConfig) { c.Algorithm = "fixed_window" },
+	} {
+		config := valid
+		change(&config)
+		if err := config.Validate(); err == nil {
+			t.Errorf("Validate(%+v) succeeded, want an error", config)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package ginmw
+
+import (
+	"errors"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nk-bm/gocore/gincore/response"
+	"go.uber.org/zap"
+)
+
+// RecoveryMW recovers from panics, logs them with the stack through zap and answers
+// with the standard error response
+func RecoveryMW(logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+
+			l := ctxLoggerOr(c, logger)
+			if isBrokenPipe(r) {
+				l.Warn("Connection closed by client", zap.Any("error", r))
+				c.Abort()
+				return
+			}
+
+			l.Error("Panic recovered", zap.Any("panic", r), zap.Stack("stack"))
+			if !c.Writer.Written() {
+				response.InternalServerError(c)
+			}
+			c.Abort()
+		}()
+
+		c.Next()
+	}
+}
+
+// isBrokenPipe reports whether the panic was caused by a connection reset by the client
+func isBrokenPipe(r any) bool {
+	err, ok := r.(error)
+	if !ok {
+		return false
+	}
+
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		return false
+	}
+	var syscallErr *os.SyscallError
+	if !errors.As(opErr, &syscallErr) {
+		return false
+	}
+
+	msg := strings.ToLower(syscallErr.Error())
+	return strings.Contains(msg, "broken pipe") || strings.Contains(msg, "connection reset by peer")
+}
@@ -0,0 +1,65 @@
+package ginmw
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nk-bm/gocore/gincore/response"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRecoveryMW(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	router := newTestRouter(func(c *gin.Context) {
+		panic("boom")
+	}, RecoveryMW(zap.New(core)))
+
+	w := serve(router, http.MethodGet, "/test", "")
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", w.Code)
+	}
+	var body response.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Code != http.StatusInternalServerError {
+		t.Fatalf("unexpected body %s", w.Body.String())
+	}
+
+	entries := logs.FilterMessage("Panic recovered").All()
+	if len(entries) != 1 || entries[0].Level != zapcore.ErrorLevel {
+		t.Fatalf("got %v, want one error entry", entries)
+	}
+	fields := entries[0].ContextMap()
+	if fields["panic"] != "boom" || fields["stack"] == "" {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+}
+
+func TestRecoveryMWKeepsWrittenResponse(t *testing.T) {
+	router := newTestRouter(func(c *gin.Context) {
+		c.String(http.StatusAccepted, "partial")
+		panic("after write")
+	}, RecoveryMW(zap.NewNop()))
+
+	w := serve(router, http.MethodGet, "/test", "")
+	if w.Code != http.StatusAccepted || w.Body.String() != "partial" {
+		t.Fatalf("got %d %q, want the written response", w.Code, w.Body.String())
+	}
+}
+
+func TestRecoveryMWBrokenPipe(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	router := newTestRouter(func(c *gin.Context) {
+		panic(&net.OpError{Op: "write", Err: os.NewSyscallError("write", syscall.EPIPE)})
+	}, RecoveryMW(zap.New(core)))
+
+	serve(router, http.MethodGet, "/test", "")
+	if entries := logs.All(); len(entries) != 1 || entries[0].Level != zapcore.WarnLevel {
+		t.Fatalf("got %v, want one warning", entries)
+	}
+}
@@ -0,0 +1,113 @@
+package ginmw
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nk-bm/gocore/gincore/static"
+	"github.com/nk-bm/gocore/goutils"
+	"go.uber.org/zap"
+)
+
+// RequestLoggerMW accepts or generates X-Request-ID, echoes it together with a valid traceparent
+// and attaches a child of logger with request fields to the gin and request contexts.
+// The trace_id and span_id fields come from the span started by TracingMW when it runs first
+func RequestLoggerMW(logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(static.REQUEST_ID_HEADER)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		c.Header(static.REQUEST_ID_HEADER, requestID)
+		c.Set(static.REQUEST_ID, requestID)
+
+		fields := []zap.Field{
+			zap.String("request_id", requestID),
+			zap.String("method", c.Request.Method),
+			zap.String("route", c.FullPath()),
+		}
+		traceFields := goutils.TraceFields(c.Request.Context())
+		if traceparent := c.GetHeader(static.TRACEPARENT_HEADER); traceparent != "" {
+			if traceID, spanID, ok := parseTraceparent(traceparent); ok {
+				c.Header(static.TRACEPARENT_HEADER, traceparent)
+				if traceFields == nil {
+					traceFields = []zap.Field{zap.String("trace_id", traceID)}
+				}
+				traceFields = append(traceFields, zap.String("parent_span_id", spanID))
+			}
+		}
+		fields = append(fields, traceFields...)
+
+		c.Set(static.LOGGER, logger)
+		AddLogFields(c, fields...)
+		c.Next()
+	}
+}
+
+// CtxLogger returns the request logger set by RequestLoggerMW or the global logger
+func CtxLogger(c *gin.Context) *zap.Logger {
+	return ctxLoggerOr(c, zap.L())
+}
+
+// AddLogFields adds fields to the request logger and to the request context, so that
+// loggers reading goutils.LogFieldsFromContext (e.g. the GORM logger) pick them up
+func AddLogFields(c *gin.Context, fields ...zap.Field) {
+	logger := CtxLogger(c).With(fields...)
+	c.Set(static.LOGGER, logger)
+
+	ctx := goutils.ContextWithLogFields(c.Request.Context(), fields...)
+	ctx = goutils.ContextWithLogger(ctx, logger)
+	c.Request = c.Request.WithContext(ctx)
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_.:", r)) {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// parseTraceparent extracts trace and parent span ids from a W3C traceparent header
+func parseTraceparent(value string) (string, string, bool) {
+	parts := strings.Split(value, "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return "", "", false
+	}
+	if parts[0] == "00" && len(parts) != 4 {
+		return "", "", false
+	}
+
+	traceID, spanID, flags := parts[1], parts[2], parts[3]
+	if len(traceID) != 32 || len(spanID) != 16 || len(flags) != 2 {
+		return "", "", false
+	}
+	if !isLowerHex(parts[0]) || !isLowerHex(traceID) || !isLowerHex(spanID) || !isLowerHex(flags) {
+		return "", "", false
+	}
+	if strings.Trim(traceID, "0") == "" || strings.Trim(spanID, "0") == "" {
+		return "", "", false
+	}
+	return traceID, spanID, true
+}
+
+func isLowerHex(s string) bool {
+	for _, r := range s {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+package ginmw
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nk-bm/gocore/goutils"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRequestLoggerMW(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	router := newTestRouter(func(c *gin.Context) {
+		AddLogFields(c, zap.Int64("user_id", 42))
+		CtxLogger(c).Info("from handler")
+		// Loggers reading the request context, like the GORM logger, get the same fields
+		goutils.LoggerFromContext(c.Request.Context()).Info("from context",
+			goutils.LogFieldsFromContext(c.Request.Context())[0])
+		c.Status(http.StatusNoContent)
+	}, RequestLoggerMW(zap.New(core)))
+
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	w := serve(router, http.MethodGet, "/test", "", "X-Request-ID: req-1", "traceparent: "+traceparent)
+	if got := w.Header().Get("X-Request-ID"); got != "req-1" {
+		t.Fatalf("X-Request-ID = %q, want the incoming id", got)
+	}
+	if got := w.Header().Get("traceparent"); got != traceparent {
+		t.Fatalf("traceparent = %q, want it echoed", got)
+	}
+
+	entries := logs.FilterMessage("from handler").All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	for key, want := range map[string]any{
+		"request_id": "req-1", "method": "GET", "route": "/test", "user_id": int64(42),
+		"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736", "parent_span_id": "00f067aa0ba902b7",
+	} {
+		if fields[key] != want {
+			t.Errorf("%s = %v, want %v", key, fields[key], want)
+		}
+	}
+	if got := logs.FilterMessage("from context").All()[0].ContextMap()["user_id"]; got != int64(42) {
+		t.Errorf("context logger user_id = %v, want 42", got)
+	}
+}
+
+func TestRequestLoggerMWGeneratesID(t *testing.T) {
+	router := newTestRouter(func(c *gin.Context) {}, RequestLoggerMW(zap.NewNop()))
+
+	tests := []string{"", "invalid id with spaces", "id/with/slashes", strings.Repeat("a", 129)}
+	for _, incoming := range tests {
+		w := serve(router, http.MethodGet, "/test", "", "X-Request-ID: "+incoming, "traceparent: 00-bad")
+		got := w.Header().Get("X-Request-ID")
+		if len(got) != 32 || got == incoming {
+			t.Errorf("X-Request-ID = %q for incoming %q, want a generated id", got, incoming)
+		}
+		if w.Header().Get("traceparent") != "" {
+			t.Errorf("invalid traceparent is echoed")
+		}
+	}
+}
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		value string
+		ok    bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false},
+	}
+	for _, tt := range tests {
+		if _, _, ok := parseTraceparent(tt.value); ok != tt.ok {
+			t.Errorf("parseTraceparent(%q) ok = %v, want %v", tt.value, ok, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package ginmw
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TelegramFrameAncestors are the origins that embed Mini Apps in Telegram Web
+var TelegramFrameAncestors = []string{"https://web.telegram.org", "https://*.telegram.org"}
+
+type SecurityHeadersConfig struct {
+	// HSTSMaxAge is sent with Strict-Transport-Security on HTTPS requests, 0 disables HSTS
+	HSTSMaxAge            time.Duration `env:"GIN_HSTS_MAX_AGE; default:8760h"`
+	HSTSIncludeSubdomains bool          `env:"GIN_HSTS_INCLUDE_SUBDOMAINS; default:true"`
+	HSTSPreload           bool          `env:"GIN_HSTS_PRELOAD; default:false"`
+
+	// ContentSecurityPolicy replaces the default policy, which allows only same-origin resources
+	// and the frame ancestors below. "-" disables the header
+	ContentSecurityPolicy string `env:"GIN_CSP"`
+	// FrameAncestors are origins allowed to embed pages in addition to the service itself,
+	// "none" forbids embedding even by the service
+	FrameAncestors []string `env:"GIN_CSP_FRAME_ANCESTORS"`
+	// AllowTelegramFrames adds TelegramFrameAncestors, so that Mini Apps open in Telegram Web
+	AllowTelegramFrames bool `env:"GIN_CSP_ALLOW_TELEGRAM_FRAMES; default:true"`
+
+	// FrameOptions is DENY, SAMEORIGIN or "-" to disable the header. By default it follows the frame
+	// ancestors and is omitted when other origins may embed pages, as it cannot list them
+	FrameOptions      string `env:"GIN_FRAME_OPTIONS"`
+	ReferrerPolicy    string `env:"GIN_REFERRER_POLICY; default:strict-origin-when-cross-origin"`
+	PermissionsPolicy string `env:"GIN_PERMISSIONS_POLICY; default:camera=(), microphone=(), geolocation=(), payment=(), usb=()"`
+}
+
+func (c SecurityHeadersConfig) Validate() error {
+	switch strings.ToUpper(c.FrameOptions) {
+	case "", "-", "DENY", "SAMEORIGIN":
+		return nil
+	default:
+		return fmt.Errorf("invalid GIN_FRAME_OPTIONS %q: must be DENY or SAMEORIGIN", c.FrameOptions)
+	}
+}
+
+// frameAncestors returns the frame-ancestors directive value and whether other origins may embed pages
+func (c SecurityHeadersConfig) frameAncestors() (string, bool) {
+	sources := []string{"'self'"}
+	for _, ancestor := range c.FrameAncestors {
+		switch ancestor = strings.TrimSpace(ancestor); ancestor {
+		case "":
+		case "none", "'none'":
+			return "'none'", false
+		case "self", "'self'":
+		default:
+			sources = append(sources, ancestor)
+		}
+	}
+	if c.AllowTelegramFrames {
+		sources = append(sources, TelegramFrameAncestors...)
+	}
+	return strings.Join(sources, " "), len(sources) > 1
+}
+
+// SecurityHeadersMW sets HSTS, CSP, X-Frame-Options, X-Content-Type-Options, Referrer-Policy and
+// Permissions-Policy. Handlers may replace a header, for example a page that needs a wider CSP
+func SecurityHeadersMW(config SecurityHeadersConfig) gin.HandlerFunc {
+	ancestors, embeddable := config.frameAncestors()
+
+	csp := config.ContentSecurityPolicy
+	if csp == "" {
+		csp = "default-src 'self'; base-uri 'self'; object-src 'none'; form-action 'self'; frame-ancestors " + ancestors
+	}
+
+	frameOptions := strings.ToUpper(config.FrameOptions)
+	if frameOptions == "" {
+		switch {
+		case ancestors == "'none'":
+			frameOptions = "DENY"
+		case !embeddable:
+			frameOptions = "SAMEORIGIN"
+		}
+	}
+
+	var hsts string
+	if config.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.FormatInt(int64(config.HSTSMaxAge/time.Second), 10)
+		if config.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if config.HSTSPreload {
+			hsts += "; preload"
+		}
+	}
+
+	return func(c *gin.Context) {
+		header := c.Writer.Header()
+		header.Set("X-Content-Type-Options", "nosniff")
+		if csp != "-" {
+			header.Set("Content-Security-Policy", csp)
+		}
+		if frameOptions != "" && frameOptions != "-" {
+			header.Set("X-Frame-Options", frameOptions)
+		}
+		if config.ReferrerPolicy != "" {
+			header.Set("Referrer-Policy", config.ReferrerPolicy)
+		}
+		if config.PermissionsPolicy != "" {
+			header.Set("Permissions-Policy", config.PermissionsPolicy)
+		}
+		// Browsers ignore HSTS received over plain HTTP
+		if hsts != "" && (c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https") {
+			header.Set("Strict-Transport-Security", hsts)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,66 @@
+package ginmw
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSecurityHeadersMW(t *testing.T) {
+	router := newTestRouter(okHandler, SecurityHeadersMW(SecurityHeadersConfig{
+		HSTSMaxAge:            time.Hour,
+		HSTSIncludeSubdomains: true,
+		ReferrerPolicy:        "no-referrer",
+	}))
+
+	w := serve(router, http.MethodGet, "/test", "")
+	header := w.Header()
+	if header.Get("X-Content-Type-Options") != "nosniff" || header.Get("Referrer-Policy") != "no-referrer" {
+		t.Errorf("headers = %v", header)
+	}
+	if csp := header.Get("Content-Security-Policy"); !strings.Contains(csp, "default-src 'self'") || !strings.HasSuffix(csp, "frame-ancestors 'self'") {
+		t.Errorf("CSP = %q, want the same-origin default", csp)
+	}
+	if got := header.Get("X-Frame-Options"); got != "SAMEORIGIN" {
+		t.Errorf("X-Frame-Options = %q, want SAMEORIGIN", got)
+	}
+	// Browsers ignore HSTS over plain HTTP
+	if got := header.Get("Strict-Transport-Security"); got != "" {
+		t.Errorf("HSTS over HTTP = %q", got)
+	}
+
+	w = serve(router, http.MethodGet, "/test", "", "X-Forwarded-Proto: https")
+	if got := w.Header().Get("Strict-Transport-Security"); got != "max-age=3600; includeSubDomains" {
+		t.Errorf("HSTS = %q", got)
+	}
+}
+
+func TestSecurityHeadersMWFrameAncestors(t *testing.T) {
+	tests := []struct {
+		config       SecurityHeadersConfig
+		ancestors    string
+		frameOptions string
+	}{
+		{SecurityHeadersConfig{AllowTelegramFrames: true}, "'self' https://web.telegram.org https://*.telegram.org", ""},
+		{SecurityHeadersConfig{FrameAncestors: []string{"none"}}, "'none'", "DENY"},
+		{SecurityHeadersConfig{FrameAncestors: []string{"https://partner.example"}}, "'self' https://partner.example", ""},
+		{SecurityHeadersConfig{FrameOptions: "deny"}, "'self'", "DENY"},
+	}
+	for _, tt := range tests {
+		w := serve(newTestRouter(okHandler, SecurityHeadersMW(tt.config)), http.MethodGet, "/test", "")
+		if csp := w.Header().Get("Content-Security-Policy"); !strings.HasSuffix(csp, "frame-ancestors "+tt.ancestors) {
+			t.Errorf("%+v: CSP = %q, want frame-ancestors %s", tt.config, csp, tt.ancestors)
+		}
+		if got := w.Header().Get("X-Frame-Options"); got != tt.frameOptions {
+			t.Errorf("%+v: X-Frame-Options = %q, want %q", tt.config, got, tt.frameOptions)
+		}
+	}
+}
+
+func TestSecurityHeadersMWDisabledCSP(t *testing.T) {
+	w := serve(newTestRouter(okHandler, SecurityHeadersMW(SecurityHeadersConfig{ContentSecurityPolicy: "-", FrameOptions: "-"})), http.MethodGet, "/test", "")
+	if w.Header().Get("Content-Security-Policy") != "" || w.Header().Get("X-Frame-Options") != "" {
+		t.Errorf("headers = %v, want CSP and X-Frame-Options disabled", w.Header())
+	}
+}
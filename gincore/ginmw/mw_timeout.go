@@ -0,0 +1,61 @@
+package ginmw
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nk-bm/gocore/gincore/response"
+)
+
+// timeoutParentKey keeps the request context before the first TimeoutMW, so that nested timeouts replace it
+const timeoutParentKey = "gocore.timeout_parent"
+
+// TimeoutMW cancels the request context after timeout. Handlers must observe the context: the middleware
+// does not abandon a running handler, but answers 503 once it returns if nothing was written.
+// Handlers served by Handle that return the context error are answered with 504 instead.
+// The innermost timeout applies, so a route group can replace the server timeout; timeout <= 0 removes it
+func TimeoutMW(timeout time.Duration) gin.HandlerFunc {
+	return TimeoutFuncMW(func(*gin.Context) time.Duration { return timeout })
+}
+
+// TimeoutFuncMW is TimeoutMW with the timeout chosen per request, for example by route
+func TimeoutFuncMW(timeoutFor func(c *gin.Context) time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout := timeoutFor(c)
+		ctx := c.Request.Context()
+		if parent, ok := c.Get(timeoutParentKey); ok {
+			// Values added since the outer timeout are kept, its deadline is dropped
+			ctx = valuesContext{Context: parent.(context.Context), values: ctx}
+		} else {
+			c.Set(timeoutParentKey, ctx)
+		}
+
+		if timeout <= 0 {
+			c.Request = c.Request.WithContext(ctx)
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			response.ServiceUnavailable(c)
+			c.Abort()
+		}
+	}
+}
+
+// valuesContext has the deadline and cancellation of Context and the values of values
+type valuesContext struct {
+	context.Context
+	values context.Context
+}
+
+func (c valuesContext) Value(key any) any {
+	return c.values.Value(key)
+}
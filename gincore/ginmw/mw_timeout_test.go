@@ -0,0 +1,53 @@
+package ginmw
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// waitHandler returns when the request context is done
+func waitHandler(c *gin.Context) {
+	<-c.Request.Context().Done()
+}
+
+func TestTimeoutMW(t *testing.T) {
+	if w := serve(newTestRouter(waitHandler, TimeoutMW(10*time.Millisecond)), http.MethodGet, "/test", ""); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("handler without a response: status %d, want 503", w.Code)
+	}
+
+	written := func(c *gin.Context) {
+		<-c.Request.Context().Done()
+		c.String(http.StatusGatewayTimeout, "timeout")
+	}
+	if w := serve(newTestRouter(written, TimeoutMW(10*time.Millisecond)), http.MethodGet, "/test", ""); w.Code != http.StatusGatewayTimeout {
+		t.Errorf("handler with a response: status %d, want its 504", w.Code)
+	}
+
+	if w := serve(newTestRouter(okHandler, TimeoutMW(time.Second)), http.MethodGet, "/test", ""); w.Code != http.StatusOK {
+		t.Errorf("fast handler: status %d, want 200", w.Code)
+	}
+}
+
+func TestTimeoutMWInnermostApplies(t *testing.T) {
+	var deadline time.Duration
+	handler := func(c *gin.Context) {
+		d, _ := c.Request.Context().Deadline()
+		deadline = time.Until(d)
+		c.Status(http.StatusOK)
+	}
+
+	serve(newTestRouter(handler, TimeoutMW(time.Second), TimeoutMW(time.Hour)), http.MethodGet, "/test", "")
+	if deadline < time.Minute {
+		t.Errorf("raised timeout: deadline in %s, want the inner hour", deadline)
+	}
+
+	removed := func(c *gin.Context) {
+		if _, ok := c.Request.Context().Deadline(); ok {
+			t.Error("removed timeout: the request context has a deadline")
+		}
+	}
+	serve(newTestRouter(removed, TimeoutMW(time.Second), TimeoutMW(0)), http.MethodGet, "/test", "")
+}
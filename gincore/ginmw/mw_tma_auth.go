@@ -10,6 +10,7 @@ import (
 	"github.com/nk-bm/gocore/gotypes"
 	"github.com/nk-bm/gocore/goutils"
 	initdata "github.com/telegram-mini-apps/init-data-golang"
+	"go.uber.org/zap"
 )
 
 func TelegramMiniAppAuthMW(telegramBotToken string, expIn time.Duration) gin.HandlerFunc {
@@ -35,6 +36,7 @@ func TelegramMiniAppAuthMW(telegramBotToken string, expIn time.Duration) gin.Han
 		}
 
 		c.Set(static.TMA_INIT_DATA, gotypes.TelegramMiniAppInitData(initData))
+		AddLogFields(c, zap.Int64("tma_user_id", initData.User.ID))
 		c.Next()
 	}
 }
@@ -0,0 +1,107 @@
+package ginmw
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	testTraceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testParentID    = "00f067aa0ba902b7"
+	testTraceparent = "00-" + testTraceID + "-" + testParentID + "-01"
+)
+
+// newTestTracer installs a tracer provider exporting to memory
+func newTestTracer(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+	return exporter
+}
+
+func spanAttribute(span tracetest.SpanStub, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestTracingMWContinuesTrace(t *testing.T) {
+	exporter := newTestTracer(t)
+	var handlerTraceID trace.TraceID
+	router := newTestRouter(func(c *gin.Context) {
+		handlerTraceID = trace.SpanContextFromContext(c.Request.Context()).TraceID()
+		c.Status(http.StatusNoContent)
+	}, TracingMW())
+
+	serve(router, http.MethodGet, "/test", "", "traceparent: "+testTraceparent)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	span := spans[0]
+	if span.Name != "GET /test" || span.SpanKind != trace.SpanKindServer {
+		t.Errorf("span = %q of kind %v, want a server span GET /test", span.Name, span.SpanKind)
+	}
+	if span.SpanContext.TraceID().String() != testTraceID || span.Parent.SpanID().String() != testParentID {
+		t.Errorf("span trace %s parent %s, want the incoming traceparent", span.SpanContext.TraceID(), span.Parent.SpanID())
+	}
+	if handlerTraceID != span.SpanContext.TraceID() {
+		t.Errorf("handler trace = %s, want the request span in the request context", handlerTraceID)
+	}
+	if got := spanAttribute(span, "http.route").AsString(); got != "/test" {
+		t.Errorf("http.route = %q", got)
+	}
+	if got := spanAttribute(span, "http.response.status_code").AsInt64(); got != http.StatusNoContent {
+		t.Errorf("http.response.status_code = %d", got)
+	}
+}
+
+func TestTracingMWRecordsErrors(t *testing.T) {
+	exporter := newTestTracer(t)
+	router := newTestRouter(func(c *gin.Context) {
+		c.Error(errors.New("payment declined"))
+		c.Status(http.StatusBadGateway)
+	}, TracingMW())
+
+	serve(router, http.MethodPost, "/test", "")
+
+	span := exporter.GetSpans()[0]
+	if span.SpanContext.TraceID().String() == testTraceID || span.Parent.IsValid() {
+		t.Errorf("span continues a trace without a traceparent")
+	}
+	if span.Status.Code != codes.Error {
+		t.Errorf("status = %v, want error", span.Status)
+	}
+	if len(span.Events) != 1 || span.Events[0].Name != "exception" {
+		t.Errorf("events = %v, want the handler error", span.Events)
+	}
+}
+
+func TestTracingMWUnmatchedRoute(t *testing.T) {
+	exporter := newTestTracer(t)
+	serve(newTestRouter(okHandler, TracingMW()), http.MethodGet, "/missing/42", "")
+
+	// Unmatched requests are named by the method only, so scanned paths do not create new span names
+	span := exporter.GetSpans()[0]
+	if span.Name != http.MethodGet || spanAttribute(span, "url.path").AsString() != "/missing/42" {
+		t.Errorf("span = %q with url.path %q", span.Name, spanAttribute(span, "url.path").AsString())
+	}
+}
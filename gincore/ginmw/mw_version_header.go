@@ -0,0 +1,15 @@
+package ginmw
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+const VersionHeader = "X-App-Version"
+
+// VersionHeaderMW adds the version of the service to every response
+func VersionHeaderMW(version string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header(VersionHeader, version)
+		c.Next()
+	}
+}
@@ -0,0 +1,290 @@
+package gincore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/nk-bm/gocore/gincore/response"
+)
+
+// HandlerFunc is a handler that does not depend on gin, so it can be called directly in tests
+type HandlerFunc[Req, Resp any] func(ctx context.Context, req Req) (Resp, error)
+
+type ginContextKey struct{}
+
+// Handle adapts fn to gin. The request is bound with Bind, validation failures are answered
+// with 422, the result is wrapped with response.Success and errors are answered with RespondError
+func Handle[Req, Resp any](fn HandlerFunc[Req, Resp]) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req Req
+		if err := Bind(c, &req); err != nil {
+			RespondError(c, err)
+			return
+		}
+
+		ctx := context.WithValue(c.Request.Context(), ginContextKey{}, c)
+		resp, err := fn(ctx, req)
+		if err != nil {
+			RespondError(c, err)
+			return
+		}
+		response.Success(c, resp)
+	}
+}
+
+// GinContext returns the gin context of a request handled by Handle
+func GinContext(ctx context.Context) (*gin.Context, bool) {
+	c, ok := ctx.Value(ginContextKey{}).(*gin.Context)
+	return c, ok
+}
+
+// Bind fills req from the JSON body and then from the "uri", "form" (query) and "header" tags,
+// so path parameters take precedence over the body. Only fields tagged with a name for a source
+// are bound from it, so a query parameter cannot replace a body field. A body of another
+// Content-Type fails with 415. Validation by the "binding" tag runs once after all sources
+// are bound and fails with an Error carrying the details of invalid fields
+func Bind(c *gin.Context, req any) error {
+	if c.Request.Body != nil && c.Request.Body != http.NoBody && c.Request.ContentLength != 0 {
+		if !isJSONContentType(c.GetHeader("Content-Type")) {
+			return NewError(http.StatusUnsupportedMediaType, "Content-Type must be application/json")
+		}
+		if err := decodeJSONBody(c.Request.Body, req); err != nil {
+			if errors.As(err, new(*http.MaxBytesError)) {
+				return WrapError(http.StatusRequestEntityTooLarge, err)
+			}
+			return &Error{Status: http.StatusBadRequest, Message: "invalid JSON body: " + err.Error(), Err: err}
+		}
+	}
+
+	if err := bindTagged(req, "uri", func(name string) []string {
+		if value, ok := c.Params.Get(name); ok {
+			return []string{value}
+		}
+		return nil
+	}); err != nil {
+		return &Error{Status: http.StatusBadRequest, Message: "invalid path parameter: " + err.Error(), Err: err}
+	}
+	query := c.Request.URL.Query()
+	if err := bindTagged(req, "form", func(name string) []string { return query[name] }); err != nil {
+		return &Error{Status: http.StatusBadRequest, Message: "invalid query parameter: " + err.Error(), Err: err}
+	}
+	if err := bindTagged(req, "header", c.Request.Header.Values); err != nil {
+		return &Error{Status: http.StatusBadRequest, Message: "invalid header: " + err.Error(), Err: err}
+	}
+
+	if binding.Validator == nil {
+		return nil
+	}
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		var validationErrors validator.ValidationErrors
+		if !errors.As(err, &validationErrors) {
+			return &Error{Status: http.StatusUnprocessableEntity, Message: err.Error(), Err: err}
+		}
+		return &Error{
+			Status:  http.StatusUnprocessableEntity,
+			Message: "Validation Failed",
+			Details: fieldErrors(reflect.TypeOf(req), validationErrors),
+			Err:     err,
+		}
+	}
+	return nil
+}
+
+// isJSONContentType accepts application/json and structured syntax types like application/problem+json
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json")
+}
+
+// decodeJSONBody decodes a single JSON value, an empty body leaves req unchanged
+func decodeJSONBody(body io.Reader, req any) error {
+	decoder := json.NewDecoder(body)
+	if err := decoder.Decode(req); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return err
+	}
+	if err := decoder.Decode(new(json.RawMessage)); !errors.Is(err, io.EOF) {
+		if err == nil || errors.As(err, new(*http.MaxBytesError)) {
+			return errors.Join(errors.New("unexpected data after the JSON value"), err)
+		}
+		return err
+	}
+	return nil
+}
+
+// bindTagged sets the fields named by tag from lookup. binding.MapFormWithTag falls back to the
+// Go field name and applies defaults of untagged fields, so the values are mapped into a new
+// value and only the fields tagged with a name are copied to req. The default of a tagged
+// field applies when the field is not set by the source or the body
+func bindTagged(req any, tag string, lookup func(name string) []string) error {
+	dst := reflect.ValueOf(req)
+	if dst.Kind() != reflect.Pointer || dst.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	values := make(map[string][]string)
+	walkTaggedFields(dst.Type().Elem(), tag, func(name string, _ reflect.StructField) {
+		if v := lookup(name); len(v) > 0 {
+			values[name] = v
+		}
+	})
+	src := reflect.New(dst.Type().Elem())
+	if err := binding.MapFormWithTag(src.Interface(), values, tag); err != nil {
+		return err
+	}
+	copyTaggedFields(dst.Elem(), src.Elem(), tag, values)
+	return nil
+}
+
+// walkTaggedFields calls fn for the fields of t with a name in tag, descending into untagged struct fields
+func walkTaggedFields(t reflect.Type, tag string, fn func(name string, field reflect.StructField)) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		switch {
+		case name == "-":
+		case name != "":
+			fn(name, field)
+		default:
+			walkTaggedFields(field.Type, tag, fn)
+		}
+	}
+}
+
+// copyTaggedFields copies the fields of src bound from values to dst
+func copyTaggedFields(dst, src reflect.Value, tag string, values map[string][]string) {
+	for i := 0; i < dst.NumField(); i++ {
+		field := dst.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, options, _ := strings.Cut(field.Tag.Get(tag), ",")
+		switch {
+		case name == "-":
+		case name != "":
+			_, ok := values[name]
+			if ok || hasDefaultOption(options) && dst.Field(i).IsZero() {
+				dst.Field(i).Set(src.Field(i))
+			}
+		case field.Type.Kind() == reflect.Struct:
+			copyTaggedFields(dst.Field(i), src.Field(i), tag, values)
+		case field.Type.Kind() == reflect.Pointer && field.Type.Elem().Kind() == reflect.Struct && !src.Field(i).IsNil():
+			if dst.Field(i).IsNil() {
+				dst.Field(i).Set(reflect.New(field.Type.Elem()))
+			}
+			copyTaggedFields(dst.Field(i).Elem(), src.Field(i).Elem(), tag, values)
+		}
+	}
+}
+
+func hasDefaultOption(options string) bool {
+	for _, option := range strings.Split(options, ",") {
+		if strings.HasPrefix(strings.TrimSpace(option), "default=") {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldErrors describes validation errors by the names clients use for the fields
+func fieldErrors(t reflect.Type, errs validator.ValidationErrors) []response.FieldError {
+	details := make([]response.FieldError, 0, len(errs))
+	for _, e := range errs {
+		details = append(details, response.FieldError{
+			Field:   requestFieldName(t, e.StructNamespace()),
+			Rule:    e.Tag(),
+			Message: validationMessage(e),
+		})
+	}
+	return details
+}
+
+// requestFieldName converts a namespace like "Req.Address.City" to "address.city"
+// using the json, uri, form or header tag of every field
+func requestFieldName(t reflect.Type, namespace string) string {
+	parts := strings.Split(namespace, ".")[1:]
+	names := make([]string, 0, len(parts))
+	for _, part := range parts {
+		for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+			t = t.Elem()
+		}
+
+		// Elements of slices and maps are addressed as Field[0]
+		fieldName, index, _ := strings.Cut(part, "[")
+		if index != "" {
+			index = "[" + index
+		}
+
+		name := fieldName
+		if t.Kind() == reflect.Struct {
+			if field, ok := t.FieldByName(fieldName); ok {
+				name = taggedName(field)
+				t = field.Type
+			}
+		}
+		names = append(names, name+index)
+	}
+	return strings.Join(names, ".")
+}
+
+func taggedName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "uri", "form", "header"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+func validationMessage(e validator.FieldError) string {
+	switch e.Tag() {
+	case "required":
+		return "is required"
+	case "min", "gte":
+		return fmt.Sprintf("must be at least %s", e.Param())
+	case "max", "lte":
+		return fmt.Sprintf("must be at most %s", e.Param())
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", e.Param())
+	case "lt":
+		return fmt.Sprintf("must be less than %s", e.Param())
+	case "len":
+		return fmt.Sprintf("must have length %s", e.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", e.Param())
+	case "email":
+		return "must be a valid email"
+	case "url":
+		return "must be a valid URL"
+	case "uuid", "uuid4":
+		return "must be a valid UUID"
+	}
+	if e.Param() != "" {
+		return fmt.Sprintf("failed on %s=%s", e.Tag(), e.Param())
+	}
+	return fmt.Sprintf("failed on %s", e.Tag())
+}
@@ -0,0 +1,194 @@
+package gincore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nk-bm/gocore/gincore/response"
+)
+
+type createOrderRequest struct {
+	ID       string `uri:"id" binding:"required"`
+	Amount   int    `json:"amount" binding:"min=1"`
+	Currency string `json:"currency" binding:"required,oneof=RUB USD"`
+	DryRun   bool   `form:"dry_run"`
+	TraceID  string `header:"X-Trace-Id"`
+}
+
+type createOrderResponse struct {
+	ID     string `json:"id"`
+	Amount int    `json:"amount"`
+	DryRun bool   `json:"dry_run"`
+	Trace  string `json:"trace"`
+}
+
+// createOrder is tested directly and through Handle
+func createOrder(_ context.Context, req createOrderRequest) (createOrderResponse, error) {
+	if req.ID == "taken" {
+		return createOrderResponse{}, fmt.Errorf("create order: %w", ConflictError("order exists"))
+	}
+	if req.ID == "broken" {
+		return createOrderResponse{}, errors.New("connection refused by 10.0.0.1")
+	}
+	return createOrderResponse{ID: req.ID, Amount: req.Amount, DryRun: req.DryRun, Trace: req.TraceID}, nil
+}
+
+func TestHandlerWithoutGin(t *testing.T) {
+	resp, err := createOrder(context.Background(), createOrderRequest{ID: "1", Amount: 5})
+	if err != nil || resp.ID != "1" || resp.Amount != 5 {
+		t.Errorf("createOrder() = %+v, %v", resp, err)
+	}
+
+	_, err = createOrder(context.Background(), createOrderRequest{ID: "taken"})
+	var httpErr *Error
+	if !errors.As(err, &httpErr) || httpErr.Status != http.StatusConflict {
+		t.Errorf("createOrder() error = %v, want a wrapped 409 Error", err)
+	}
+}
+
+func newHandleTestServer(t *testing.T) *Server {
+	s := newTestServer(t, nil)
+	s.RegisterRoutes([]Route{NewRoute(http.MethodPost, "/orders/:id", createOrder)})
+	return s
+}
+
+func TestHandleBindsAllSources(t *testing.T) {
+	s := newHandleTestServer(t)
+	w := serve(s, http.MethodPost, "/api/v1/orders/42?dry_run=true", `{"id":"body","amount":3,"currency":"RUB"}`,
+		"Content-Type: application/json; charset=utf-8", "X-Trace-Id: trace-1")
+	assertStatus(t, w, http.StatusOK)
+
+	var body struct {
+		Data createOrderResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	want := createOrderResponse{ID: "42", Amount: 3, DryRun: true, Trace: "trace-1"}
+	if body.Data != want {
+		t.Errorf("data = %+v, want %+v", body.Data, want)
+	}
+}
+
+func TestHandleErrors(t *testing.T) {
+	s := newHandleTestServer(t)
+	tests := []struct {
+		name        string
+		path        string
+		body        string
+		contentType string
+		status      int
+		message     string
+	}{
+		{"invalid JSON", "/api/v1/orders/1", `{"amount":`, "application/json", http.StatusBadRequest, ""},
+		{"text body", "/api/v1/orders/1", `{"amount":1,"currency":"RUB"}`, "text/plain", http.StatusUnsupportedMediaType, ""},
+		{"form body", "/api/v1/orders/1", `amount=1`, "application/x-www-form-urlencoded", http.StatusUnsupportedMediaType, ""},
+		{"no content type", "/api/v1/orders/1", `{"amount":1,"currency":"RUB"}`, "", http.StatusUnsupportedMediaType, ""},
+		{"invalid query", "/api/v1/orders/1?dry_run=maybe", `{"amount":1,"currency":"RUB"}`, "application/json", http.StatusBadRequest, ""},
+		{"handler error", "/api/v1/orders/taken", `{"amount":1,"currency":"RUB"}`, "application/json", http.StatusConflict, "order exists"},
+		{"plain error", "/api/v1/orders/broken", `{"amount":1,"currency":"RUB"}`, "application/json", http.StatusInternalServerError, "Internal Server Error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(s, http.MethodPost, tt.path, tt.body, "Content-Type: "+tt.contentType)
+			assertStatus(t, w, tt.status)
+			if tt.message == "" {
+				return
+			}
+			var body response.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Error != tt.message {
+				t.Errorf("error = %q, want %q", body.Error, tt.message)
+			}
+		})
+	}
+}
+
+func TestHandleValidationDetails(t *testing.T) {
+	s := newHandleTestServer(t)
+	w := serve(s, http.MethodPost, "/api/v1/orders/1", `{"amount":0,"currency":"EUR"}`, "Content-Type: application/json")
+	assertStatus(t, w, http.StatusUnprocessableEntity)
+
+	var body response.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	want := []response.FieldError{
+		{Field: "amount", Rule: "min", Message: "must be at least 1"},
+		{Field: "currency", Rule: "oneof", Message: "must be one of: RUB USD"},
+	}
+	if fmt.Sprint(body.Details) != fmt.Sprint(want) {
+		t.Errorf("details = %+v, want %+v", body.Details, want)
+	}
+}
+
+func TestErrorConstructorsReturnNewErrors(t *testing.T) {
+	first := NotFoundError("")
+	first.Message = "changed"
+	if second := NotFoundError(""); second.Error() != "Not Found" || second == first {
+		t.Errorf("NotFoundError() = %v, want a new error with the status text", second)
+	}
+}
+
+func TestRespondErrorStatuses(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{BadRequestError("bad"), http.StatusBadRequest},
+		{fmt.Errorf("wrapped: %w", ForbiddenError("")), http.StatusForbidden},
+		{&http.MaxBytesError{Limit: 1}, http.StatusRequestEntityTooLarge},
+		{context.DeadlineExceeded, http.StatusGatewayTimeout},
+		{context.Canceled, StatusClientClosedRequest},
+	}
+	for _, tt := range tests {
+		s := newTestServer(t, nil)
+		s.Router.GET("/err", func(c *gin.Context) { RespondError(c, tt.err) })
+		assertStatus(t, serve(s, http.MethodGet, "/err", ""), tt.status)
+	}
+}
+
+func TestBindOnlyTaggedFields(t *testing.T) {
+	var req struct {
+		Amount int `json:"amount"`
+		Note   string
+		Fee    int `json:"fee" form:",default=10"`
+		Page   int `form:"page,default=1"`
+		Inner  struct {
+			Sort string `form:"sort"`
+		}
+	}
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/?Amount=5&amount=7&Note=query&Fee=1&sort=name", strings.NewReader(`{"amount":3,"fee":2}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Request.Header.Set("Amount", "6")
+
+	if err := Bind(c, &req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Amount != 3 || req.Note != "" || req.Fee != 2 {
+		t.Errorf("req = %+v, want untagged fields left to the body", req)
+	}
+	if req.Page != 1 || req.Inner.Sort != "name" {
+		t.Errorf("req = %+v, want the tagged default and nested query parameter", req)
+	}
+}
+
+func TestBindRejectsTrailingData(t *testing.T) {
+	s := newHandleTestServer(t)
+	for _, body := range []string{`{"amount":3,"currency":"RUB"} {"amount":9}`, `{"amount":3,"currency":"RUB"}x`} {
+		w := serve(s, http.MethodPost, "/api/v1/orders/42", body, "Content-Type: application/json")
+		assertStatus(t, w, http.StatusBadRequest)
+	}
+	assertStatus(t, serve(s, http.MethodPost, "/api/v1/orders/42", "{\"amount\":3,\"currency\":\"RUB\"}\n",
+		"Content-Type: application/json"), http.StatusOK)
+}
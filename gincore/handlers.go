@@ -1,14 +1,110 @@
 package gincore
 
 import (
+	"bytes"
+	"net/http"
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"github.com/nk-bm/gocore/env"
 	"github.com/nk-bm/gocore/gincore/response"
+	"github.com/nk-bm/gocore/goutils"
 )
 
 type HealthCheckResponse struct {
 	OK bool `json:"ok"`
 }
 
+// HealthCheckHandler always responds ok, the checks of Server.Health are run by ProbeHandler
 func HealthCheckHandler(c *gin.Context) {
 	response.Success(c, HealthCheckResponse{OK: true})
 }
+
+// ConfigHandler returns the loaded configuration with secrets masked.
+// The format query parameter selects json (default), table, markdown or env output
+func ConfigHandler(config any, opts env.LoadOptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		description, err := env.DescribeWithOptions(config, opts)
+		if err != nil {
+			response.Error(c, err, http.StatusInternalServerError)
+			return
+		}
+
+		format := c.Query("format")
+		if format == "" || format == "json" {
+			response.Success(c, description)
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := description.Render(&buf, format); err != nil {
+			response.BadRequestWithMessage(c, err.Error())
+			return
+		}
+		c.String(http.StatusOK, buf.String())
+	}
+}
+
+// LevelController reads and changes levels of named loggers
+type LevelController interface {
+	All() map[string]string
+	SetLevel(name, level string, ttl time.Duration) error
+}
+
+type SetLogLevelRequest struct {
+	Logger string `json:"logger" binding:"required"`
+	Level  string `json:"level" binding:"required"`
+	// TTL is a duration like "15m" after which the previous level is restored
+	TTL string `json:"ttl"`
+}
+
+func LogLevelsHandler(levels LevelController) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		response.Success(c, levels.All())
+	}
+}
+
+func SetLogLevelHandler(levels LevelController) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req SetLogLevelRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequestWithMessage(c, err.Error())
+			return
+		}
+
+		var ttl time.Duration
+		if req.TTL != "" {
+			var err error
+			if ttl, err = time.ParseDuration(req.TTL); err != nil {
+				response.BadRequestWithMessage(c, "invalid ttl: "+err.Error())
+				return
+			}
+		}
+
+		if err := levels.SetLevel(req.Logger, req.Level, ttl); err != nil {
+			response.BadRequestWithMessage(c, err.Error())
+			return
+		}
+		response.Success(c, levels.All())
+	}
+}
+
+// RoutesHandler returns the route table of the server
+func RoutesHandler(s *Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		response.Success(c, s.Routes())
+	}
+}
+
+// VersionHandler returns the build metadata of the service without the module list,
+// which would tell clients the versions of the dependencies
+func VersionHandler(c *gin.Context) {
+	info := goutils.GetBuildInfo()
+	info.Modules = nil
+	response.Success(c, info)
+}
+
+// BuildInfoHandler returns the build metadata with the versions of all modules, for the admin server
+func BuildInfoHandler(c *gin.Context) {
+	response.Success(c, goutils.GetBuildInfo())
+}
@@ -0,0 +1,63 @@
+package gincore
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeLevels struct {
+	levels map[string]string
+	ttl    time.Duration
+}
+
+func (l *fakeLevels) All() map[string]string {
+	return l.levels
+}
+
+func (l *fakeLevels) SetLevel(name, level string, ttl time.Duration) error {
+	l.levels[name] = level
+	l.ttl = ttl
+	return nil
+}
+
+func TestLogLevelHandlers(t *testing.T) {
+	s := newTestServer(t, func(c *Config) {
+		c.AdminToken = "admin-token"
+		c.Options.EnableLogLevelHandler = true
+	})
+	levels := &fakeLevels{levels: map[string]string{"db": "info"}}
+	s.RegisterLogLevelHandlers(levels)
+
+	assertStatus(t, serve(s, http.MethodGet, "/admin/log-level", ""), http.StatusUnauthorized)
+	assertStatus(t, serve(s, http.MethodGet, "/admin/log-level", "", "Authorization: Bearer wrong"), http.StatusUnauthorized)
+
+	w := serve(s, http.MethodGet, "/admin/log-level", "", "Authorization: Bearer admin-token")
+	assertStatus(t, w, http.StatusOK)
+	if !strings.Contains(w.Body.String(), `"db":"info"`) {
+		t.Fatalf("unexpected body %s", w.Body.String())
+	}
+
+	w = serve(s, http.MethodPut, "/admin/log-level", `{"logger":"db","level":"debug","ttl":"15m"}`,
+		"Authorization: Bearer admin-token", "Content-Type: application/json")
+	assertStatus(t, w, http.StatusOK)
+	if levels.levels["db"] != "debug" || levels.ttl != 15*time.Minute {
+		t.Fatalf("got levels %v, ttl %v", levels.levels, levels.ttl)
+	}
+
+	w = serve(s, http.MethodPut, "/admin/log-level", `{"logger":"db","level":"debug","ttl":"soon"}`,
+		"Authorization: Bearer admin-token", "Content-Type: application/json")
+	assertStatus(t, w, http.StatusBadRequest)
+}
+
+func TestVersionHandlerIsOptIn(t *testing.T) {
+	assertStatus(t, serve(newTestServer(t, nil), http.MethodGet, "/version", ""), http.StatusNotFound)
+
+	s := newTestServer(t, func(config *Config) { config.Options.EnableVersionHandler = true })
+	w := serve(s, http.MethodGet, "/version", "")
+	assertStatus(t, w, http.StatusOK)
+	if !strings.Contains(w.Body.String(), `"version":`) || strings.Contains(w.Body.String(), `"modules"`) {
+		t.Errorf("/version = %s, want the version without modules", w.Body.String())
+	}
+}
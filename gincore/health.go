@@ -0,0 +1,53 @@
+package gincore
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nk-bm/gocore/gincore/health"
+	"github.com/nk-bm/gocore/gincore/response"
+)
+
+type HealthConfig struct {
+	// Timeout is the default timeout of a check
+	Timeout time.Duration `env:"GIN_HEALTH_TIMEOUT; default:5s"`
+	// CacheTTL is how long check results are reused by the probes
+	CacheTTL time.Duration `env:"GIN_HEALTH_CACHE_TTL; default:1s"`
+	// ShutdownDelay is how long the server keeps serving after readiness starts failing on shutdown
+	ShutdownDelay time.Duration `env:"GIN_HEALTH_SHUTDOWN_DELAY; default:0s"`
+}
+
+// Health returns the registry of the checks served by the probe endpoints
+func (s *Server) Health() *health.Registry {
+	return s.health
+}
+
+// registerHealthHandlers serves the probes on router. /health keeps responding ok without running checks,
+// since existing deployments use it as a liveness probe
+func (s *Server) registerHealthHandlers(router gin.IRoutes) {
+	router.GET("/health", HealthCheckHandler)
+	router.GET("/health/live", ProbeHandler(s.health, health.Liveness))
+	router.GET("/health/ready", ProbeHandler(s.health, health.Readiness))
+	router.GET("/health/startup", ProbeHandler(s.health, health.Startup))
+}
+
+// ProbeHandler responds with the statuses of the checks of the probe, with status 503 when it fails.
+// Check errors are only logged by the registry, since the probes are not authenticated
+func ProbeHandler(registry *health.Registry, probe health.Probe) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := registry.Run(c.Request.Context(), probe).Public()
+		if !report.OK() {
+			c.JSON(http.StatusServiceUnavailable, response.Response{
+				Success: false,
+				Data:    report,
+				Error: &response.ErrorResponse{
+					Code:  http.StatusServiceUnavailable,
+					Error: string(probe) + " check failed",
+				},
+			})
+			return
+		}
+		response.Success(c, report)
+	}
+}
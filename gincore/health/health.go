@@ -0,0 +1,270 @@
+// Package health runs named checks for the liveness, readiness and startup probes
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+type Probe string
+
+const (
+	// Liveness fails when the process must be restarted, it should not depend on other services
+	Liveness Probe = "liveness"
+	// Readiness fails when the instance must not receive traffic
+	Readiness Probe = "readiness"
+	// Startup fails until the instance has finished starting
+	Startup Probe = "startup"
+)
+
+type Status string
+
+const (
+	StatusUp Status = "up"
+	// StatusDegraded means that only optional checks failed, the probe still passes
+	StatusDegraded Status = "degraded"
+	StatusDown     Status = "down"
+)
+
+type CheckFunc func(ctx context.Context) error
+
+type Check struct {
+	Name  string
+	Check CheckFunc
+	// Probes default to Readiness
+	Probes []Probe
+	// Timeout defaults to the timeout of the registry
+	Timeout time.Duration
+	// Optional checks are reported but do not fail the probe
+	Optional bool
+}
+
+type CheckResult struct {
+	Status Status `json:"status"`
+	// Error may reveal internal hosts and addresses, Public drops it
+	Error      string    `json:"error,omitempty"`
+	Optional   bool      `json:"optional,omitempty"`
+	DurationMs float64   `json:"duration_ms"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+type Report struct {
+	Status Status                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// OK reports whether the probe passes
+func (r Report) OK() bool {
+	return r.Status != StatusDown
+}
+
+// Public returns the report without check errors, for unauthenticated probe endpoints
+func (r Report) Public() Report {
+	checks := make(map[string]CheckResult, len(r.Checks))
+	for name, result := range r.Checks {
+		result.Error = ""
+		checks[name] = result
+	}
+	return Report{Status: r.Status, Checks: checks}
+}
+
+// shutdownCheck is the check reported by readiness after SetShuttingDown
+const shutdownCheck = "shutdown"
+
+type registeredCheck struct {
+	Check
+
+	mu        sync.Mutex
+	result    CheckResult
+	expiresAt time.Time
+}
+
+// Registry keeps the checks of a service. Results are cached for the cache TTL and concurrent
+// probes share a single run of a check, so frequent probes do not overload dependencies.
+// Failed checks are logged with their errors
+type Registry struct {
+	timeout  time.Duration
+	cacheTTL time.Duration
+	logger   *zap.Logger
+
+	mu     sync.RWMutex
+	checks []*registeredCheck
+
+	group        singleflight.Group
+	shuttingDown atomic.Bool
+}
+
+func NewRegistry(timeout, cacheTTL time.Duration, logger *zap.Logger) *Registry {
+	return &Registry{timeout: timeout, cacheTTL: cacheTTL, logger: logger}
+}
+
+func (r *Registry) Register(check Check) error {
+	if check.Name == "" {
+		return errors.New("health check name is empty")
+	}
+	if check.Check == nil {
+		return fmt.Errorf("health check %q has no function", check.Name)
+	}
+	if len(check.Probes) == 0 {
+		check.Probes = []Probe{Readiness}
+	}
+	if check.Timeout <= 0 {
+		check.Timeout = r.timeout
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, registered := range r.checks {
+		if registered.Name == check.Name {
+			return fmt.Errorf("health check %q is already registered", check.Name)
+		}
+	}
+	r.checks = append(r.checks, &registeredCheck{Check: check})
+	return nil
+}
+
+// MustRegister is like Register but panics on error
+func (r *Registry) MustRegister(checks ...Check) {
+	for _, check := range checks {
+		if err := r.Register(check); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// SetShuttingDown makes readiness fail, so that load balancers stop sending traffic before the server stops
+func (r *Registry) SetShuttingDown() {
+	r.shuttingDown.Store(true)
+}
+
+func (r *Registry) ShuttingDown() bool {
+	return r.shuttingDown.Load()
+}
+
+// Run runs the checks of the probe concurrently, a probe without checks is up
+func (r *Registry) Run(ctx context.Context, probe Probe) Report {
+	r.mu.RLock()
+	var checks []*registeredCheck
+	for _, check := range r.checks {
+		for _, p := range check.Probes {
+			if p == probe {
+				checks = append(checks, check)
+				break
+			}
+		}
+	}
+	r.mu.RUnlock()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = r.result(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]CheckResult, len(checks)+1)}
+	for i, check := range checks {
+		report.Checks[check.Name] = results[i]
+		if results[i].Status == StatusDown {
+			if check.Optional {
+				if report.Status == StatusUp {
+					report.Status = StatusDegraded
+				}
+			} else {
+				report.Status = StatusDown
+			}
+		}
+	}
+	if probe == Readiness && r.ShuttingDown() {
+		report.Status = StatusDown
+		report.Checks[shutdownCheck] = CheckResult{
+			Status:    StatusDown,
+			Error:     "server is shutting down",
+			CheckedAt: time.Now(),
+		}
+	}
+	return report
+}
+
+// result returns the cached result of the check or runs it once for all concurrent callers
+func (r *Registry) result(ctx context.Context, check *registeredCheck) CheckResult {
+	check.mu.Lock()
+	if time.Now().Before(check.expiresAt) {
+		defer check.mu.Unlock()
+		return check.result
+	}
+	check.mu.Unlock()
+
+	// The check is not bound to the context of the caller, which may be canceled while others wait
+	ch := r.group.DoChan(check.Name, func() (any, error) {
+		result := run(check.Check)
+		if result.Status == StatusDown {
+			r.logger.Warn("Health check failed",
+				zap.String("check", check.Name),
+				zap.Bool("optional", check.Optional),
+				zap.String("error", result.Error),
+			)
+		}
+		check.mu.Lock()
+		check.result = result
+		check.expiresAt = time.Now().Add(r.cacheTTL)
+		check.mu.Unlock()
+		return result, nil
+	})
+	select {
+	case res := <-ch:
+		return res.Val.(CheckResult)
+	case <-ctx.Done():
+		return CheckResult{
+			Status:    StatusDown,
+			Error:     ctx.Err().Error(),
+			Optional:  check.Optional,
+			CheckedAt: time.Now(),
+		}
+	}
+}
+
+func run(check Check) CheckResult {
+	ctx, cancel := context.WithTimeout(context.Background(), check.Timeout)
+	defer cancel()
+
+	// A check that ignores its context still fails after the timeout, a panicking check fails the probe
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("panic: %v", p)
+			}
+		}()
+		done <- check.Check(ctx)
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out after %s", check.Timeout)
+	}
+	result := CheckResult{
+		Status:     StatusUp,
+		Optional:   check.Optional,
+		DurationMs: float64(time.Since(start).Nanoseconds()) / float64(time.Millisecond),
+		CheckedAt:  start,
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,130 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestRegistryProbes(t *testing.T) {
+	r := NewRegistry(time.Second, 0, zap.NewNop())
+	r.MustRegister(
+		Check{Name: "postgres", Check: func(context.Context) error { return errors.New("connection refused") }},
+		Check{Name: "deadlock", Check: func(context.Context) error { return nil }, Probes: []Probe{Liveness}},
+	)
+
+	ready := r.Run(context.Background(), Readiness)
+	if ready.OK() || ready.Checks["postgres"].Error != "connection refused" {
+		t.Errorf("readiness = %+v, want the failed postgres check", ready)
+	}
+	if _, ok := ready.Checks["deadlock"]; ok {
+		t.Error("readiness runs the liveness check")
+	}
+	if live := r.Run(context.Background(), Liveness); !live.OK() || len(live.Checks) != 1 {
+		t.Errorf("liveness = %+v, want only the passing liveness check", live)
+	}
+	if startup := r.Run(context.Background(), Startup); startup.Status != StatusUp || len(startup.Checks) != 0 {
+		t.Errorf("startup = %+v, want up without checks", startup)
+	}
+}
+
+func TestRegistryOptionalChecks(t *testing.T) {
+	r := NewRegistry(time.Second, 0, zap.NewNop())
+	r.MustRegister(Check{Name: "cache", Check: func(context.Context) error { return errors.New("down") }, Optional: true})
+
+	report := r.Run(context.Background(), Readiness)
+	if report.Status != StatusDegraded || !report.OK() {
+		t.Errorf("report = %+v, want degraded and passing", report)
+	}
+}
+
+func TestRegistryTimeout(t *testing.T) {
+	r := NewRegistry(time.Second, 0, zap.NewNop())
+	block := make(chan struct{})
+	defer close(block)
+	r.MustRegister(Check{Name: "slow", Timeout: 10 * time.Millisecond, Check: func(context.Context) error {
+		<-block
+		return nil
+	}})
+
+	report := r.Run(context.Background(), Readiness)
+	if report.OK() || report.Checks["slow"].Error != "timed out after 10ms" {
+		t.Errorf("report = %+v, want a timeout of the check ignoring its context", report)
+	}
+}
+
+func TestRegistryCachesResults(t *testing.T) {
+	r := NewRegistry(time.Second, time.Minute, zap.NewNop())
+	var calls atomic.Int32
+	r.MustRegister(Check{Name: "postgres", Check: func(context.Context) error {
+		calls.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}})
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.Run(context.Background(), Readiness)
+		}()
+	}
+	wg.Wait()
+	r.Run(context.Background(), Readiness)
+	if n := calls.Load(); n != 1 {
+		t.Errorf("check ran %d times, want once for concurrent and cached probes", n)
+	}
+}
+
+func TestRegistryShuttingDown(t *testing.T) {
+	r := NewRegistry(time.Second, 0, zap.NewNop())
+	r.SetShuttingDown()
+
+	if ready := r.Run(context.Background(), Readiness); ready.OK() {
+		t.Errorf("readiness = %+v, want down while shutting down", ready)
+	}
+	if live := r.Run(context.Background(), Liveness); !live.OK() {
+		t.Errorf("liveness = %+v, want up while shutting down", live)
+	}
+}
+
+func TestRegistryRejectsInvalidChecks(t *testing.T) {
+	r := NewRegistry(time.Second, 0, zap.NewNop())
+	ok := func(context.Context) error { return nil }
+	if err := r.Register(Check{Check: ok}); err == nil {
+		t.Error("Register accepted a check without a name")
+	}
+	if err := r.Register(Check{Name: "x"}); err == nil {
+		t.Error("Register accepted a check without a function")
+	}
+	r.MustRegister(Check{Name: "x", Check: ok})
+	if err := r.Register(Check{Name: "x", Check: ok}); err == nil {
+		t.Error("Register accepted a duplicate name")
+	}
+}
+
+func TestRegistryRecoversPanics(t *testing.T) {
+	r := NewRegistry(time.Second, 0, zap.NewNop())
+	r.MustRegister(Check{Name: "buggy", Check: func(context.Context) error { panic("nil map") }})
+
+	report := r.Run(context.Background(), Readiness)
+	if report.OK() || report.Checks["buggy"].Error != "panic: nil map" {
+		t.Errorf("report = %+v, want the panicking check down", report)
+	}
+}
+
+func TestReportPublic(t *testing.T) {
+	r := NewRegistry(time.Second, 0, zap.NewNop())
+	r.MustRegister(Check{Name: "postgres", Check: func(context.Context) error { return errors.New("dial tcp 10.0.0.5:5432") }})
+
+	report := r.Run(context.Background(), Readiness).Public()
+	if result := report.Checks["postgres"]; report.OK() || result.Status != StatusDown || result.Error != "" {
+		t.Errorf("public report = %+v, want the status without the error", report)
+	}
+}
@@ -0,0 +1,48 @@
+package gincore
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/nk-bm/gocore/gincore/health"
+)
+
+func TestHealthEndpoints(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.Health().MustRegister(health.Check{
+		Name:   "postgres",
+		Check:  func(context.Context) error { return errors.New("connection refused") },
+		Probes: []health.Probe{health.Readiness, health.Startup},
+	})
+
+	// The existing endpoint keeps its payload and does not depend on the database
+	w := serve(s, http.MethodGet, "/health", "")
+	assertStatus(t, w, http.StatusOK)
+	if !strings.HasPrefix(w.Body.String(), `{"success":true,"data":{"ok":true}`) {
+		t.Errorf("/health = %s, want the always-ok payload", w.Body.String())
+	}
+	assertStatus(t, serve(s, http.MethodGet, "/health/live", ""), http.StatusOK)
+
+	for _, path := range []string{"/health/ready", "/health/startup"} {
+		w := serve(s, http.MethodGet, path, "")
+		assertStatus(t, w, http.StatusServiceUnavailable)
+		body := w.Body.String()
+		if !strings.Contains(body, `"postgres":{"status":"down"`) || strings.Contains(body, "connection refused") {
+			t.Errorf("%s = %s, want the failed check without its error", path, body)
+		}
+	}
+}
+
+func TestHealthReadinessFailsOnShutdown(t *testing.T) {
+	s := newTestServer(t, nil)
+	assertStatus(t, serve(s, http.MethodGet, "/health/ready", ""), http.StatusOK)
+
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	assertStatus(t, serve(s, http.MethodGet, "/health/ready", ""), http.StatusServiceUnavailable)
+	assertStatus(t, serve(s, http.MethodGet, "/health", ""), http.StatusOK)
+}
@@ -0,0 +1,58 @@
+package gincore
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nk-bm/gocore/env"
+	"go.uber.org/zap"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newTestServer creates a server from the env defaults changed by configure
+func newTestServer(t *testing.T, configure func(*Config)) *Server {
+	t.Helper()
+	var config Config
+	if err := env.LoadEnv(&config); err != nil {
+		t.Fatal(err)
+	}
+	config.Options.DisableRequestLogger = true
+	config.AccessLog.Disable = true
+	if configure != nil {
+		configure(&config)
+	}
+	s, err := NewServer(config, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// serve sends a request to the router, headers are "Name: value" pairs
+func serve(s *Server, method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	for _, header := range headers {
+		name, value, _ := strings.Cut(header, ":")
+		req.Header.Set(name, strings.TrimSpace(value))
+	}
+	w := httptest.NewRecorder()
+	s.Router.ServeHTTP(w, req)
+	return w
+}
+
+func assertStatus(t *testing.T, w *httptest.ResponseRecorder, want int) {
+	t.Helper()
+	if w.Code != want {
+		t.Fatalf("status = %d, want %d, body %s", w.Code, want, w.Body.String())
+	}
+}
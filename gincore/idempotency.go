@@ -0,0 +1,43 @@
+package gincore
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nk-bm/gocore/gincore/ginmw"
+	"github.com/nk-bm/gocore/gostore"
+)
+
+// SetIdempotencyStore replaces the in-memory store. It applies to routes registered afterwards
+func (s *Server) SetIdempotencyStore(store gostore.IdempotencyStore) {
+	if s.config.Options.EnableIdempotency {
+		s.idempotencyStore = store
+	}
+}
+
+// IdempotencyMW returns the Idempotency-Key middleware for routes registered outside of RegisterRoute,
+// nil when EnableIdempotency is off. It must run after authentication, keys are stored per user
+func (s *Server) IdempotencyMW() gin.HandlerFunc {
+	if s.idempotencyStore == nil {
+		return nil
+	}
+	return ginmw.IdempotencyMW(ginmw.IdempotencyOptions{
+		Config: s.config.Idempotency,
+		Store:  s.idempotencyStore,
+		Logger: s.logger,
+	})
+}
+
+// idempotencyMW returns the middleware for routes with unsafe methods and an auth provider, which runs
+// before it. Keys sent to routes without a user would be shared by all callers, so they are not applied
+func (s *Server) idempotencyMW(route Route, auth string) gin.HandlerFunc {
+	if auth == "" || auth == AuthNone {
+		return nil
+	}
+	switch route.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return s.IdempotencyMW()
+	default:
+		return nil
+	}
+}
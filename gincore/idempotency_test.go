@@ -0,0 +1,53 @@
+package gincore
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestIdempotencyAppliesAfterAuth(t *testing.T) {
+	s := newTestServer(t, func(config *Config) { config.Options.EnableIdempotency = true })
+	s.RegisterAuthProvider("test", func(c *gin.Context) {
+		if c.GetHeader("X-User") == "" {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		c.Set("user_id", c.GetHeader("X-User"))
+		c.Next()
+	})
+	var calls int
+	handler := func(c *gin.Context) {
+		calls++
+		c.String(http.StatusCreated, strconv.Itoa(calls))
+	}
+	s.RegisterGroup(RouteGroup{
+		Prefix: "/payments",
+		Meta:   RouteMeta{Auth: "test"},
+		Routes: []Route{
+			{Method: http.MethodPost, Path: "/", Handler: handler},
+			{Method: http.MethodPost, Path: "/public", Handler: handler, Meta: RouteMeta{Auth: AuthNone}},
+		},
+	})
+
+	// Rejected requests do not claim the key
+	assertStatus(t, serve(s, http.MethodPost, "/api/v1/payments/", "{}", "Idempotency-Key: k1"), http.StatusUnauthorized)
+	first := serve(s, http.MethodPost, "/api/v1/payments/", "{}", "X-User: alice", "Idempotency-Key: k1")
+	retry := serve(s, http.MethodPost, "/api/v1/payments/", "{}", "X-User: alice", "Idempotency-Key: k1")
+	if calls != 1 || retry.Body.String() != first.Body.String() {
+		t.Errorf("retry ran the handler: %d calls, bodies %q and %q", calls, first.Body.String(), retry.Body.String())
+	}
+	other := serve(s, http.MethodPost, "/api/v1/payments/", "{}", "X-User: bob", "Idempotency-Key: k1")
+	if calls != 2 || other.Body.String() == first.Body.String() {
+		t.Errorf("another user received the stored response %q", other.Body.String())
+	}
+
+	// Routes without an auth provider have no user to scope keys by
+	serve(s, http.MethodPost, "/api/v1/payments/public", "{}", "Idempotency-Key: k2")
+	serve(s, http.MethodPost, "/api/v1/payments/public", "{}", "Idempotency-Key: k2")
+	if calls != 4 {
+		t.Errorf("handler ran %d times, want keys ignored on public routes", calls)
+	}
+}
@@ -0,0 +1,79 @@
+package gincore
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func bodySizeHandler(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.Status(http.StatusRequestEntityTooLarge)
+		return
+	}
+	c.String(http.StatusOK, strconv.Itoa(len(body)))
+}
+
+func TestLimitsAreOptIn(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.RegisterRoutes([]Route{{Method: http.MethodPost, Path: "/upload", Handler: bodySizeHandler}})
+
+	// Without configured limits large bodies and any Content-Encoding reach the handler as before
+	w := serve(s, http.MethodPost, "/api/v1/upload", strings.Repeat("a", 11<<20), "Content-Encoding: deflate")
+	assertStatus(t, w, http.StatusOK)
+	if w.Body.String() != strconv.Itoa(11<<20) {
+		t.Errorf("body = %s bytes, want the whole body", w.Body.String())
+	}
+}
+
+func TestLimitsOfRoutes(t *testing.T) {
+	s := newTestServer(t, func(config *Config) {
+		config.Limits.MaxBodySize = 8
+		config.Limits.RequestTimeout = time.Hour
+	})
+	s.RegisterGroup(RouteGroup{
+		Prefix:      "/files",
+		MaxBodySize: 16,
+		Routes: []Route{
+			{Method: http.MethodPost, Path: "/upload", Handler: bodySizeHandler},
+			{Method: http.MethodPost, Path: "/slow", Handler: func(c *gin.Context) { <-c.Request.Context().Done() }, Timeout: 10 * time.Millisecond},
+		},
+	})
+	s.RegisterRoutes([]Route{{Method: http.MethodPost, Path: "/upload", Handler: bodySizeHandler}})
+
+	assertStatus(t, serve(s, http.MethodPost, "/api/v1/upload", "123456789"), http.StatusRequestEntityTooLarge)
+	assertStatus(t, serve(s, http.MethodPost, "/api/v1/files/upload", "123456789"), http.StatusOK)
+	assertStatus(t, serve(s, http.MethodPost, "/api/v1/files/slow", ""), http.StatusServiceUnavailable)
+}
+
+func TestDecompressionOption(t *testing.T) {
+	s := newTestServer(t, func(config *Config) {
+		config.Options.EnableDecompression = true
+		config.Limits.MaxBodySize = 100
+	})
+	s.RegisterRoutes([]Route{{Method: http.MethodPost, Path: "/upload", Handler: bodySizeHandler}})
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte(strings.Repeat("a", 50)))
+	gz.Close()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/upload", &compressed)
+	req.Header.Set("Content-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	s.Router.ServeHTTP(w, req)
+	assertStatus(t, w, http.StatusOK)
+	if w.Body.String() != "50" {
+		t.Errorf("body = %s bytes, want the decompressed 50", w.Body.String())
+	}
+
+	assertStatus(t, serve(s, http.MethodPost, "/api/v1/upload", "x", "Content-Encoding: deflate"), http.StatusUnsupportedMediaType)
+}
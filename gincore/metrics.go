@@ -0,0 +1,108 @@
+package gincore
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/nk-bm/gocore/gincore/ginmw"
+	"github.com/nk-bm/gocore/goutils"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+)
+
+type MetricsConfig struct {
+	Path string `env:"GIN_METRICS_PATH; default:/metrics"`
+	// Port serves metrics on a separate listener of Host. With 0 they are served on the main server
+	// and require the admin token, like the other admin endpoints
+	Port      int       `env:"GIN_METRICS_PORT; default:0"`
+	Namespace string    `env:"GIN_METRICS_NAMESPACE"`
+	Buckets   []float64 `env:"GIN_METRICS_BUCKETS"`
+}
+
+// Metrics returns the registry served at the metrics path, nil when EnableMetrics is off.
+// Applications register their own collectors with it
+func (s *Server) Metrics() *prometheus.Registry {
+	return s.metrics
+}
+
+// initMetrics creates the registry and installs the HTTP metrics middleware
+func (s *Server) initMetrics() error {
+	options := s.config.Options
+	s.metrics = prometheus.NewRegistry()
+	if !options.DisableRuntimeMetrics {
+		err := errors.Join(
+			s.metrics.Register(collectors.NewGoCollector()),
+			s.metrics.Register(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{})),
+		)
+		if err != nil {
+			return fmt.Errorf("register runtime metrics: %w", err)
+		}
+	}
+	info := goutils.GetBuildInfo()
+	buildInfo := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: s.config.Metrics.Namespace,
+		Name:      "build_info",
+		Help:      "Build metadata of the service, the value is always 1.",
+		ConstLabels: prometheus.Labels{
+			"version":     info.Version,
+			"commit":      info.Commit,
+			"build_time":  info.BuildTime,
+			"commit_time": info.CommitTime,
+			"go_version":  info.GoVersion,
+		},
+	})
+	buildInfo.Set(1)
+	if err := s.metrics.Register(buildInfo); err != nil {
+		return fmt.Errorf("register build info metric: %w", err)
+	}
+	if !options.DisableHTTPMetrics {
+		httpMetrics := ginmw.NewHTTPMetrics(s.config.Metrics.Namespace, s.config.Metrics.Buckets)
+		if err := httpMetrics.Register(s.metrics); err != nil {
+			return fmt.Errorf("register HTTP metrics: %w", err)
+		}
+		s.Router.Use(ginmw.MetricsMW(httpMetrics))
+	}
+	return nil
+}
+
+func (s *Server) metricsHandler() http.Handler {
+	return promhttp.HandlerFor(s.metrics, promhttp.HandlerOpts{
+		Registry: s.metrics,
+		ErrorLog: zap.NewStdLog(s.logger),
+	})
+}
+
+// startMetricsServer serves metrics on the separate port when one is configured and there is no admin server
+func (s *Server) startMetricsServer() error {
+	if s.metrics == nil || s.config.Metrics.Port == 0 || s.config.AdminServer {
+		return nil
+	}
+
+	addr := net.JoinHostPort(s.config.Host, fmt.Sprint(s.config.Metrics.Port))
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listen metrics: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(s.config.Metrics.Path, s.metricsHandler())
+	s.metricsServer = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: s.config.HTTP.ReadHeaderTimeout,
+		ReadTimeout:       s.config.HTTP.ReadTimeout,
+		WriteTimeout:      s.config.HTTP.WriteTimeout,
+		IdleTimeout:       s.config.HTTP.IdleTimeout,
+		ErrorLog:          zap.NewStdLog(s.logger),
+	}
+	go func() {
+		if err := s.metricsServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Error("Metrics server stopped", zap.Error(err))
+		}
+	}()
+	s.logger.Info("Metrics server started", zap.String("addr", listener.Addr().String()))
+	return nil
+}
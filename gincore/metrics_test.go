@@ -0,0 +1,47 @@
+package gincore
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/nk-bm/gocore/env"
+	"go.uber.org/zap"
+)
+
+func TestMetricsRequireAdminToken(t *testing.T) {
+	s := newTestServer(t, func(config *Config) {
+		config.Options.EnableMetrics = true
+		config.AdminToken = "secret"
+	})
+	s.RegisterRoutes([]Route{{Method: http.MethodGet, Path: "/ping/:id", Handler: okHandler}})
+
+	assertStatus(t, serve(s, http.MethodGet, "/api/v1/ping/1", ""), http.StatusOK)
+	assertStatus(t, serve(s, http.MethodGet, "/missing/1", ""), http.StatusNotFound)
+	assertStatus(t, serve(s, http.MethodGet, "/metrics", ""), http.StatusUnauthorized)
+
+	w := serve(s, http.MethodGet, "/metrics", "", "Authorization: Bearer secret")
+	assertStatus(t, w, http.StatusOK)
+	for _, want := range []string{
+		`http_requests_total{method="GET",route="/api/v1/ping/:id",status="200"} 1`,
+		`http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		"build_info{",
+		"go_goroutines",
+	} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("metrics do not contain %s", want)
+		}
+	}
+}
+
+func TestNewServerRejectsInvalidMetrics(t *testing.T) {
+	var config Config
+	if err := env.LoadEnv(&config); err != nil {
+		t.Fatal(err)
+	}
+	config.Options.EnableMetrics = true
+	config.Metrics.Namespace = "invalid-namespace"
+	if _, err := NewServer(config, zap.NewNop()); err == nil {
+		t.Error("NewServer accepted metrics that cannot be registered")
+	}
+}
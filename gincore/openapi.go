@@ -0,0 +1,284 @@
+package gincore
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nk-bm/gocore/gincore/openapi"
+	"github.com/nk-bm/gocore/gincore/response"
+	"github.com/nk-bm/gocore/gincore/static"
+	swaggerfiles "github.com/swaggo/files/v2"
+	"go.uber.org/zap"
+)
+
+type OpenAPIConfig struct {
+	Path string `env:"GIN_OPENAPI_PATH; default:/openapi.json"`
+	// UI is none, swagger or redoc. Swagger UI is bundled with the binary, Redoc is loaded
+	// from a CDN at a pinned version and requires RedocIntegrity
+	UI     string `env:"GIN_OPENAPI_UI; default:none"`
+	UIPath string `env:"GIN_OPENAPI_UI_PATH; default:/docs"`
+	// RedocIntegrity is the Subresource Integrity hash of redocScriptURL, e.g. "sha384-..."
+	RedocIntegrity string `env:"GIN_OPENAPI_REDOC_INTEGRITY"`
+	Title          string `env:"GIN_OPENAPI_TITLE; default:API"`
+	Version        string `env:"GIN_OPENAPI_VERSION; default:1.0.0"`
+	Description    string `env:"GIN_OPENAPI_DESCRIPTION"`
+}
+
+// redocScriptURL is the pinned Redoc bundle loaded by the redoc page
+const redocScriptURL = "https://cdn.redoc.ly/redoc/v2.1.5/bundles/redoc.standalone.js"
+
+func (c OpenAPIConfig) Validate() error {
+	switch c.UI {
+	case "none", "swagger":
+	case "redoc":
+		if !strings.HasPrefix(c.RedocIntegrity, "sha256-") && !strings.HasPrefix(c.RedocIntegrity, "sha384-") &&
+			!strings.HasPrefix(c.RedocIntegrity, "sha512-") {
+			return fmt.Errorf("GIN_OPENAPI_REDOC_INTEGRITY must be the sha256, sha384 or sha512 integrity hash of %s", redocScriptURL)
+		}
+	default:
+		return fmt.Errorf("unknown GIN_OPENAPI_UI %q: expected none, swagger or redoc", c.UI)
+	}
+	return nil
+}
+
+// securityScheme documents an auth provider in the OpenAPI spec
+type securityScheme struct {
+	name   string
+	scheme *openapi.SecurityScheme
+}
+
+func defaultSecuritySchemes() map[string]securityScheme {
+	return map[string]securityScheme{
+		AuthBearer: {name: "bearerAuth", scheme: openapi.BearerJWT()},
+		AuthTMA: {name: "tmaAuth", scheme: openapi.APIKeyHeader(static.TMA_TOKEN_KEY,
+			"Telegram Mini App init data, as \"initdata <data>\" or raw")},
+	}
+}
+
+// RegisterSecurityScheme documents routes using the auth provider with the scheme stored under name
+func (s *Server) RegisterSecurityScheme(auth, name string, scheme *openapi.SecurityScheme) {
+	s.securitySchemes[auth] = securityScheme{name: name, scheme: scheme}
+}
+
+// OpenAPI builds the OpenAPI document of the routes registered through Server
+func (s *Server) OpenAPI() *openapi.Document {
+	config := s.config.OpenAPI
+	generator := openapi.NewGenerator()
+	errorSchema := generator.Define("ErrorResponse", reflect.TypeOf(response.ErrorResponse{}))
+	envelopeSchema := generator.Define("Response", reflect.TypeOf(response.Response{}))
+
+	doc := &openapi.Document{
+		OpenAPI: openapi.Version,
+		Info: openapi.Info{
+			Title:       config.Title,
+			Version:     config.Version,
+			Description: config.Description,
+		},
+		Paths: make(map[string]*openapi.PathItem),
+		Components: openapi.Components{
+			SecuritySchemes: make(map[string]*openapi.SecurityScheme),
+		},
+	}
+
+	errorResponse := func(status int) *openapi.Response {
+		return &openapi.Response{
+			Description: http.StatusText(status),
+			Content:     map[string]*openapi.MediaType{"application/json": {Schema: errorSchema}},
+		}
+	}
+
+	for _, route := range s.routes {
+		operation := &openapi.Operation{
+			OperationID: route.Name,
+			Summary:     route.Summary,
+			Description: route.Description,
+			Tags:        route.Tags,
+			Deprecated:  route.Deprecated,
+			Responses:   make(map[string]*openapi.Response),
+		}
+
+		data := &openapi.Schema{}
+		if route.ResponseType != nil {
+			data = generator.Schema(route.ResponseType)
+		}
+		operation.Responses["200"] = &openapi.Response{
+			Description: http.StatusText(http.StatusOK),
+			Content: map[string]*openapi.MediaType{"application/json": {Schema: &openapi.Schema{
+				AllOf: []*openapi.Schema{envelopeSchema, {
+					Type:       "object",
+					Properties: map[string]*openapi.Schema{"data": data},
+				}},
+			}}},
+		}
+
+		if route.RequestType != nil {
+			operation.Parameters = generator.Parameters(route.RequestType)
+			if route.Method != http.MethodGet && route.Method != http.MethodHead {
+				if body := generator.BodySchema(route.RequestType); body != nil {
+					operation.RequestBody = &openapi.RequestBody{
+						Required: true,
+						Content:  map[string]*openapi.MediaType{"application/json": {Schema: body}},
+					}
+					operation.Responses["415"] = errorResponse(http.StatusUnsupportedMediaType)
+				}
+			}
+			operation.Responses["400"] = errorResponse(http.StatusBadRequest)
+			operation.Responses["422"] = errorResponse(http.StatusUnprocessableEntity)
+		}
+		operation.Parameters = addPathParameters(operation.Parameters, route.Path)
+
+		if auth := route.Meta.Auth; auth != "" && auth != AuthNone {
+			operation.Responses["401"] = errorResponse(http.StatusUnauthorized)
+			if scheme, ok := s.securitySchemes[auth]; ok {
+				doc.Components.SecuritySchemes[scheme.name] = scheme.scheme
+				operation.Security = []openapi.SecurityRequirement{{scheme.name: {}}}
+			}
+		}
+		operation.Responses["500"] = errorResponse(http.StatusInternalServerError)
+
+		path := openAPIPath(route.Path)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = &openapi.PathItem{}
+		}
+		(*doc.Paths[path])[strings.ToLower(route.Method)] = operation
+	}
+
+	doc.Components.Schemas = generator.Schemas()
+	return doc
+}
+
+var pathParameter = regexp.MustCompile(`[:*]([^/]+)`)
+
+// openAPIPath converts gin parameters like /users/:id and /files/*path to /users/{id} and /files/{path}
+func openAPIPath(path string) string {
+	return pathParameter.ReplaceAllString(path, "{$1}")
+}
+
+// addPathParameters documents path parameters of the route that the request type does not declare
+func addPathParameters(parameters []*openapi.Parameter, path string) []*openapi.Parameter {
+	for _, match := range pathParameter.FindAllStringSubmatch(path, -1) {
+		declared := false
+		for _, parameter := range parameters {
+			if parameter.In == "path" && parameter.Name == match[1] {
+				declared = true
+				break
+			}
+		}
+		if !declared {
+			parameters = append(parameters, &openapi.Parameter{
+				Name:     match[1],
+				In:       "path",
+				Required: true,
+				Schema:   &openapi.Schema{Type: "string"},
+			})
+		}
+	}
+	return parameters
+}
+
+func (s *Server) registerOpenAPIHandlers() {
+	config := s.config.OpenAPI
+	s.Router.GET(config.Path, OpenAPIHandler(s))
+
+	switch config.UI {
+	case "swagger":
+		s.Router.GET(config.UIPath, OpenAPIUIHandler(config))
+		s.Router.GET(joinPaths(config.UIPath, "assets/*file"), swaggerAssetsHandler(config.Path))
+	case "redoc":
+		s.Router.GET(config.UIPath, OpenAPIUIHandler(config))
+	}
+}
+
+// OpenAPIHandler serves the OpenAPI document of the server
+func OpenAPIHandler(s *Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, s.OpenAPI())
+	}
+}
+
+var openAPIPages = map[string]*template.Template{
+	"swagger": template.Must(template.New("swagger").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<link rel="stylesheet" href="{{.AssetsPath}}/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="{{.AssetsPath}}/swagger-ui-bundle.js"></script>
+<script src="{{.AssetsPath}}/swagger-initializer.js"></script>
+</body>
+</html>`)),
+	"redoc": template.Must(template.New("redoc").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+</head>
+<body>
+<redoc spec-url="{{.SpecURL}}"></redoc>
+<script src="` + redocScriptURL + `" integrity="{{.Integrity}}" crossorigin="anonymous"></script>
+</body>
+</html>`)),
+}
+
+// openAPIPagePolicies replace the Content-Security-Policy of ginmw.SecurityHeadersMW,
+// which does not allow the scripts of the pages
+var openAPIPagePolicies = map[string]string{
+	"swagger": "default-src 'self'; style-src 'self' 'unsafe-inline'; img-src 'self' data:; frame-ancestors 'self'",
+	"redoc": "default-src 'self'; script-src " + redocScriptURL + "; worker-src blob:; " +
+		"style-src 'self' 'unsafe-inline' https://fonts.googleapis.com; font-src https://fonts.gstatic.com; " +
+		"img-src 'self' data: https:; frame-ancestors 'self'",
+}
+
+// OpenAPIUIHandler serves a Swagger UI or Redoc page for the document at config.Path.
+// Swagger UI assets are served from UIPath/assets by the server
+func OpenAPIUIHandler(config OpenAPIConfig) gin.HandlerFunc {
+	page := openAPIPages[config.UI]
+	data := struct{ Title, SpecURL, AssetsPath, Integrity string }{
+		Title:      config.Title,
+		SpecURL:    config.Path,
+		AssetsPath: joinPaths(config.UIPath, "assets"),
+		Integrity:  config.RedocIntegrity,
+	}
+	return func(c *gin.Context) {
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		if c.Writer.Header().Get("Content-Security-Policy") != "" {
+			c.Header("Content-Security-Policy", openAPIPagePolicies[config.UI])
+		}
+		c.Status(http.StatusOK)
+		if err := page.Execute(c.Writer, data); err != nil {
+			Logger(c).Error("Failed to render OpenAPI page", zap.Error(err))
+		}
+	}
+}
+
+// swaggerAssetsHandler serves the Swagger UI distribution bundled with the binary.
+// The initializer script is rendered with the spec URL, since the page may not run inline scripts
+func swaggerAssetsHandler(specURL string) gin.HandlerFunc {
+	specJSON, _ := json.Marshal(specURL)
+	initializer := []byte(`window.onload = function() {
+  window.ui = SwaggerUIBundle({url: ` + string(specJSON) + `, dom_id: "#swagger-ui"});
+};
+`)
+	files := http.FileServer(http.FS(swaggerfiles.FS))
+	return func(c *gin.Context) {
+		file := strings.TrimPrefix(c.Param("file"), "/")
+		switch file {
+		case "swagger-initializer.js":
+			c.Data(http.StatusOK, "text/javascript; charset=utf-8", initializer)
+		case "swagger-ui.css", "swagger-ui-bundle.js":
+			c.Request.URL.Path = "/" + file
+			files.ServeHTTP(c.Writer, c.Request)
+		default:
+			c.Status(http.StatusNotFound)
+		}
+	}
+}
@@ -0,0 +1,338 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Generator derives schemas from Go types following encoding/json and gin binding tags.
+// Named struct types are added to the component schemas once and referenced by $ref
+type Generator struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func NewGenerator() *Generator {
+	return &Generator{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+// Schemas returns the component schemas collected so far
+func (g *Generator) Schemas() map[string]*Schema {
+	return g.schemas
+}
+
+// Define adds a component schema for t under name, so it is referenced by that name
+func (g *Generator) Define(name string, t reflect.Type) *Schema {
+	t = indirect(t)
+	g.names[t] = name
+	g.schemas[name] = &Schema{}
+	*g.schemas[name] = *g.structSchema(t, false)
+	return Ref(name)
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	durationType      = reflect.TypeOf(time.Duration(0))
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Schema returns the schema of t as encoding/json marshals it
+func (g *Generator) Schema(t reflect.Type) *Schema {
+	t = indirect(t)
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == durationType:
+		return &Schema{Type: "integer", Format: "int64", Description: "duration in nanoseconds"}
+	case implements(t, jsonMarshalerType):
+		return &Schema{}
+	case implements(t, textMarshalerType):
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32", Minimum: float(0)}
+	case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		return &Schema{Type: "integer", Format: "int64", Minimum: float(0)}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.Schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.Schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t, false)
+		}
+		if name, ok := g.names[t]; ok {
+			return Ref(name)
+		}
+		name := g.uniqueName(t)
+		g.names[t] = name
+		// The placeholder lets recursive types refer to themselves
+		g.schemas[name] = &Schema{}
+		*g.schemas[name] = *g.structSchema(t, false)
+		return Ref(name)
+	default:
+		return &Schema{}
+	}
+}
+
+// BodySchema returns the schema of the fields of t bound from the JSON body,
+// leaving out fields bound from the path, query or headers. It returns nil if no field is left
+func (g *Generator) BodySchema(t reflect.Type) *Schema {
+	t = indirect(t)
+	if t.Kind() != reflect.Struct {
+		return g.Schema(t)
+	}
+	schema := g.structSchema(t, true)
+	if len(schema.Properties) == 0 {
+		return nil
+	}
+	return schema
+}
+
+var parameterTags = map[string]string{"uri": "path", "form": "query", "header": "header"}
+
+// Parameters returns the path, query and header parameters declared by the uri, form and header tags of t
+func (g *Generator) Parameters(t reflect.Type) []*Parameter {
+	t = indirect(t)
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var parameters []*Parameter
+	for _, field := range visibleFields(t) {
+		for _, tag := range []string{"uri", "form", "header"} {
+			value, ok := field.Tag.Lookup(tag)
+			if !ok {
+				continue
+			}
+			name, options, _ := strings.Cut(value, ",")
+			if name == "" || name == "-" {
+				continue
+			}
+
+			schema := g.Schema(field.Type)
+			applyRules(schema, field)
+			if def, ok := strings.CutPrefix(options, "default="); ok {
+				schema.Default = defaultValue(schema, def)
+			}
+			parameters = append(parameters, &Parameter{
+				Name:        name,
+				In:          parameterTags[tag],
+				Description: field.Tag.Get("doc"),
+				Required:    tag == "uri" || hasRule(field, "required"),
+				Schema:      schema,
+			})
+		}
+	}
+	return parameters
+}
+
+func (g *Generator) structSchema(t reflect.Type, body bool) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for _, field := range visibleFields(t) {
+		jsonTag, hasJSON := field.Tag.Lookup("json")
+		name, options, _ := strings.Cut(jsonTag, ",")
+		if name == "-" {
+			continue
+		}
+		if body && !hasJSON && isParameter(field) {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := g.Schema(field.Type)
+		if strings.Contains(options, "string") {
+			property = &Schema{Type: "string"}
+		}
+		applyRules(property, field)
+		if doc := field.Tag.Get("doc"); doc != "" {
+			property.Description = doc
+		}
+		schema.Properties[name] = property
+		if hasRule(field, "required") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
+
+// visibleFields returns the exported fields of t with fields of embedded structs
+// without a json name promoted, as encoding/json does
+func visibleFields(t reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if field.Anonymous && name == "" && indirect(field.Type).Kind() == reflect.Struct {
+			fields = append(fields, visibleFields(indirect(field.Type))...)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+func isParameter(field reflect.StructField) bool {
+	for tag := range parameterTags {
+		if _, ok := field.Tag.Lookup(tag); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// rules returns the validator rules of the field that apply to the field itself, not to elements
+func rules(field reflect.StructField) []string {
+	var result []string
+	for _, rule := range strings.Split(field.Tag.Get("binding"), ",") {
+		if rule == "dive" {
+			break
+		}
+		if rule != "" {
+			result = append(result, rule)
+		}
+	}
+	return result
+}
+
+func hasRule(field reflect.StructField, name string) bool {
+	for _, rule := range rules(field) {
+		if rule == name {
+			return true
+		}
+	}
+	return false
+}
+
+func applyRules(schema *Schema, field reflect.StructField) {
+	kind := indirect(field.Type).Kind()
+	for _, rule := range rules(field) {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "min", "gte":
+			setBound(schema, kind, param, &schema.Minimum, &schema.MinLength, &schema.MinItems)
+		case "max", "lte":
+			setBound(schema, kind, param, &schema.Maximum, &schema.MaxLength, &schema.MaxItems)
+		case "len":
+			setBound(schema, kind, param, nil, &schema.MinLength, &schema.MinItems)
+			setBound(schema, kind, param, nil, &schema.MaxLength, &schema.MaxItems)
+		case "gt":
+			setBound(schema, kind, param, &schema.ExclusiveMinimum, nil, nil)
+		case "lt":
+			setBound(schema, kind, param, &schema.ExclusiveMaximum, nil, nil)
+		case "oneof":
+			for _, value := range strings.Fields(param) {
+				schema.Enum = append(schema.Enum, value)
+			}
+		case "email":
+			schema.Format = "email"
+		case "url":
+			schema.Format = "uri"
+		case "uuid", "uuid4":
+			schema.Format = "uuid"
+		}
+	}
+}
+
+func setBound(schema *Schema, kind reflect.Kind, param string, number **float64, length, items **int) {
+	switch kind {
+	case reflect.String:
+		if n, err := strconv.Atoi(param); err == nil && length != nil {
+			*length = &n
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if n, err := strconv.Atoi(param); err == nil && items != nil {
+			*items = &n
+		}
+	default:
+		if n, err := strconv.ParseFloat(param, 64); err == nil && number != nil {
+			*number = &n
+		}
+	}
+}
+
+var (
+	qualifiedName = regexp.MustCompile(`[\w\-./]*\.(\w+)`)
+	unsafeName    = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+)
+
+// uniqueName turns "Page[github.com/acme/api.User]" into "Page_User", adding a number on collisions
+func (g *Generator) uniqueName(t reflect.Type) string {
+	base := qualifiedName.ReplaceAllString(t.Name(), "$1")
+	base = strings.Trim(unsafeName.ReplaceAllString(base, "_"), "_")
+
+	name := base
+	for i := 2; ; i++ {
+		if _, taken := g.schemas[name]; !taken {
+			return name
+		}
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+}
+
+// defaultValue converts the default of a form tag to the type of the schema
+func defaultValue(schema *Schema, value string) any {
+	switch schema.Type {
+	case "integer":
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PointerTo(t).Implements(iface)
+}
+
+func float(v float64) *float64 {
+	return &v
+}
@@ -0,0 +1,136 @@
+package gincore
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func newOpenAPITestServer(t *testing.T, configure func(*Config)) *Server {
+	s := newTestServer(t, func(config *Config) {
+		config.Options.EnableOpenAPI = true
+		if configure != nil {
+			configure(config)
+		}
+	})
+	s.RegisterAuthProvider(AuthBearer, okHandler)
+	route := NewRoute(http.MethodPost, "/orders/:id", createOrder)
+	route.Name = "createOrder"
+	route.Meta.Auth = AuthBearer
+	s.RegisterRoutes([]Route{route, {Method: http.MethodGet, Path: "/ping", Handler: okHandler}})
+	return s
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	s := newOpenAPITestServer(t, nil)
+	doc := s.OpenAPI()
+
+	operation := (*doc.Paths["/api/v1/orders/{id}"])["post"]
+	if operation == nil || operation.OperationID != "createOrder" {
+		t.Fatalf("paths = %v, want the createOrder operation", doc.Paths)
+	}
+
+	var parameters []string
+	for _, p := range operation.Parameters {
+		parameters = append(parameters, p.In+":"+p.Name)
+	}
+	if !slices.Equal(parameters, []string{"path:id", "query:dry_run", "header:X-Trace-Id"}) {
+		t.Errorf("parameters = %v", parameters)
+	}
+
+	body := operation.RequestBody.Content["application/json"].Schema
+	if _, ok := body.Properties["id"]; ok {
+		t.Error("the path parameter is documented in the body")
+	}
+	if amount := body.Properties["amount"]; amount == nil || amount.Minimum == nil || *amount.Minimum != 1 {
+		t.Errorf("amount = %+v, want minimum 1", amount)
+	}
+	if !slices.Equal(body.Required, []string{"currency"}) || len(body.Properties["currency"].Enum) != 2 {
+		t.Errorf("currency is not documented as a required enum: %+v", body)
+	}
+
+	for _, status := range []string{"200", "400", "401", "415", "422", "500"} {
+		if operation.Responses[status] == nil {
+			t.Errorf("response %s is missing", status)
+		}
+	}
+	if len(operation.Security) != 1 || doc.Components.SecuritySchemes["bearerAuth"] == nil {
+		t.Errorf("security = %v, schemes = %v", operation.Security, doc.Components.SecuritySchemes)
+	}
+	if ping := (*doc.Paths["/api/v1/ping"])["get"]; ping == nil || ping.Security != nil || ping.RequestBody != nil {
+		t.Errorf("ping = %+v, want an operation without security and body", ping)
+	}
+}
+
+func TestOpenAPIHandler(t *testing.T) {
+	s := newOpenAPITestServer(t, nil)
+	w := serve(s, http.MethodGet, "/openapi.json", "")
+	assertStatus(t, w, http.StatusOK)
+
+	var doc map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc["openapi"] != "3.1.0" {
+		t.Errorf("openapi = %v", doc["openapi"])
+	}
+}
+
+func TestOpenAPISwaggerUIIsBundled(t *testing.T) {
+	s := newOpenAPITestServer(t, func(config *Config) {
+		config.OpenAPI.UI = "swagger"
+		config.Options.EnableSecurityHeaders = true
+	})
+
+	w := serve(s, http.MethodGet, "/docs", "")
+	assertStatus(t, w, http.StatusOK)
+	page := w.Body.String()
+	if strings.Contains(page, "https://") || !strings.Contains(page, `src="/docs/assets/swagger-ui-bundle.js"`) {
+		t.Errorf("page = %s, want only bundled assets", page)
+	}
+	if csp := w.Header().Get("Content-Security-Policy"); strings.Contains(csp, "https:") || strings.Contains(csp, "script-src") {
+		t.Errorf("Content-Security-Policy = %s, want scripts from self only", csp)
+	}
+
+	w = serve(s, http.MethodGet, "/docs/assets/swagger-ui-bundle.js", "")
+	assertStatus(t, w, http.StatusOK)
+	if !strings.Contains(w.Body.String(), "SwaggerUIBundle") {
+		t.Error("swagger-ui-bundle.js is not served")
+	}
+	w = serve(s, http.MethodGet, "/docs/assets/swagger-initializer.js", "")
+	assertStatus(t, w, http.StatusOK)
+	if !strings.Contains(w.Body.String(), `url: "/openapi.json"`) {
+		t.Errorf("initializer = %s, want the spec URL", w.Body.String())
+	}
+	assertStatus(t, serve(s, http.MethodGet, "/docs/assets/index.html", ""), http.StatusNotFound)
+}
+
+func TestOpenAPIRedocIsPinned(t *testing.T) {
+	integrity := "sha384-test"
+	s := newOpenAPITestServer(t, func(config *Config) {
+		config.OpenAPI.UI = "redoc"
+		config.OpenAPI.RedocIntegrity = integrity
+	})
+
+	page := serve(s, http.MethodGet, "/docs", "").Body.String()
+	if !strings.Contains(page, `src="`+redocScriptURL+`" integrity="`+integrity+`" crossorigin="anonymous"`) {
+		t.Errorf("page = %s, want the pinned script with its integrity hash", page)
+	}
+}
+
+func TestOpenAPIConfigValidate(t *testing.T) {
+	valid := []OpenAPIConfig{{UI: "none"}, {UI: "swagger"}, {UI: "redoc", RedocIntegrity: "sha384-abc"}}
+	for _, config := range valid {
+		if err := config.Validate(); err != nil {
+			t.Errorf("Validate(%+v) = %v", config, err)
+		}
+	}
+	invalid := []OpenAPIConfig{{UI: "rapidoc"}, {UI: "redoc"}, {UI: "redoc", RedocIntegrity: "abc"}}
+	for _, config := range invalid {
+		if err := config.Validate(); err == nil {
+			t.Errorf("Validate(%+v) succeeded, want an error", config)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package gincore
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nk-bm/gocore/gincore/ginmw"
+	"github.com/nk-bm/gocore/gostore"
+)
+
+func (s *Server) initRateLimit() error {
+	limits, err := s.config.RateLimit.Limits()
+	if err != nil {
+		return fmt.Errorf("invalid rate limit config: %w", err)
+	}
+	key, err := s.config.RateLimit.KeyFunc()
+	if err != nil {
+		return fmt.Errorf("invalid rate limit config: %w", err)
+	}
+	s.rateLimits = limits
+	s.rateLimitKey = key
+	s.rateLimitByIP = s.config.RateLimit.Key == "ip"
+	s.rateLimitStore = gostore.NewMemoryRateLimitStore()
+	return nil
+}
+
+// RegisterRateLimitClass adds a limit selected by RouteMeta.RateLimitClass, the empty name is the default limit
+func (s *Server) RegisterRateLimitClass(name string, limit gostore.RateLimit) {
+	if s.rateLimits == nil {
+		s.rateLimits = make(map[string]gostore.RateLimit)
+	}
+	s.rateLimits[name] = limit
+}
+
+// SetRateLimitStore replaces the in-memory store. It applies to routes registered afterwards
+func (s *Server) SetRateLimitStore(store gostore.RateLimitStore) {
+	if s.config.Options.EnableRateLimit {
+		s.rateLimitStore = store
+	}
+}
+
+// SetRateLimitKey replaces the key function selected by GIN_RATE_LIMIT_KEY.
+// The key may depend on the user, so the limiter runs after authentication
+func (s *Server) SetRateLimitKey(key ginmw.RateLimitKeyFunc) {
+	s.rateLimitKey = key
+	s.rateLimitByIP = false
+}
+
+// rateLimitMW returns the limiters of the class, nil when the route is not limited.
+// Limits counted by IP run before authentication. Limits counted per user run after it,
+// and failed authentications are limited by IP before it.
+// It panics on unknown classes, like registerRoute does for unknown auth providers
+func (s *Server) rateLimitMW(route Route, class string) (beforeAuth, afterAuth gin.HandlerFunc) {
+	if s.rateLimitStore == nil || class == RateLimitNone {
+		return nil, nil
+	}
+	limit, ok := s.rateLimits[class]
+	if !ok {
+		panic(fmt.Sprintf("route %s %s uses unknown rate limit class %q", route.Method, route.Path, class))
+	}
+
+	name := class
+	if name == "" {
+		name = "default"
+	}
+	opts := ginmw.RateLimitOptions{
+		Name:     name,
+		Limit:    limit,
+		Store:    s.rateLimitStore,
+		Key:      s.rateLimitKey,
+		FailOpen: s.config.RateLimit.FailOpen,
+		Logger:   s.logger,
+	}
+	if s.rateLimitByIP {
+		return ginmw.RateLimitMW(opts), nil
+	}
+	failures := opts
+	failures.Key = ginmw.KeyByIP
+	return ginmw.RateLimitFailuresMW(failures), ginmw.RateLimitMW(opts)
+}
@@ -0,0 +1,128 @@
+package gincore
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nk-bm/gocore/env"
+	"go.uber.org/zap"
+)
+
+// newRateLimitTestServer limits every route to 2 requests per minute with the given key, behind the "test" auth provider
+func newRateLimitTestServer(t *testing.T, key string) *Server {
+	t.Helper()
+	s := newTestServer(t, func(config *Config) {
+		config.Options.EnableRateLimit = true
+		config.RateLimit.Limit = 2
+		config.RateLimit.Key = key
+	})
+	s.RegisterAuthProvider("test", func(c *gin.Context) {
+		if c.GetHeader("X-User") != "alice" {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		c.Set("user_id", "alice")
+		c.Writer.Header().Add("X-Order", "auth")
+		c.Next()
+	})
+	s.RegisterGroup(RouteGroup{
+		Prefix: "/orders",
+		Meta:   RouteMeta{Auth: "test"},
+		Routes: []Route{{Method: http.MethodGet, Path: "/", Handler: okHandler}},
+	})
+	return s
+}
+
+func TestNewServerRejectsInvalidRateLimitConfig(t *testing.T) {
+	var config Config
+	if err := env.LoadEnv(&config); err != nil {
+		t.Fatal(err)
+	}
+	config.Options.EnableRateLimit = true
+	config.RateLimit.Classes = []string{"login=5"}
+	if _, err := NewServer(config, zap.NewNop()); err == nil || !strings.Contains(err.Error(), "rate limit") {
+		t.Errorf("NewServer error = %v, want an invalid rate limit config", err)
+	}
+}
+
+func TestRateLimitByIPRunsBeforeAuth(t *testing.T) {
+	s := newRateLimitTestServer(t, "ip")
+
+	assertStatus(t, serve(s, http.MethodGet, "/api/v1/orders/", ""), http.StatusUnauthorized)
+	assertStatus(t, serve(s, http.MethodGet, "/api/v1/orders/", "", "X-User: alice"), http.StatusOK)
+	w := serve(s, http.MethodGet, "/api/v1/orders/", "", "X-User: alice")
+	assertStatus(t, w, http.StatusTooManyRequests)
+	if w.Header().Get("X-Order") != "" {
+		t.Errorf("order = %v, want the request rejected before auth", w.Header().Values("X-Order"))
+	}
+}
+
+func TestRateLimitThrottlesFailedAuth(t *testing.T) {
+	s := newRateLimitTestServer(t, "auto")
+
+	for range 2 {
+		assertStatus(t, serve(s, http.MethodGet, "/api/v1/orders/", "", "X-User: mallory"), http.StatusUnauthorized)
+	}
+	w := serve(s, http.MethodGet, "/api/v1/orders/", "", "X-User: mallory")
+	assertStatus(t, w, http.StatusTooManyRequests)
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("Retry-After is missing")
+	}
+}
+
+func TestRateLimitPerUserAfterAuth(t *testing.T) {
+	s := newRateLimitTestServer(t, "user")
+
+	for range 2 {
+		assertStatus(t, serve(s, http.MethodGet, "/api/v1/orders/", "", "X-User: alice"), http.StatusOK)
+	}
+	w := serve(s, http.MethodGet, "/api/v1/orders/", "", "X-User: alice")
+	assertStatus(t, w, http.StatusTooManyRequests)
+	if got := w.Header().Values("X-Order"); len(got) != 1 || got[0] != "auth" {
+		t.Errorf("order = %v, want the user limited after auth", got)
+	}
+}
+
+func TestRateLimitByIPIgnoresSpoofedForwardedFor(t *testing.T) {
+	s := newRateLimitTestServer(t, "ip")
+
+	for i, ip := range []string{"203.0.113.1", "203.0.113.2"} {
+		w := serve(s, http.MethodGet, "/api/v1/orders/", "", "X-User: alice", "X-Forwarded-For: "+ip)
+		assertStatus(t, w, http.StatusOK)
+		if got := w.Header().Get("RateLimit-Remaining"); got != fmt.Sprint(1-i) {
+			t.Errorf("remaining = %q after X-Forwarded-For %s, want the remote address to key the limit", got, ip)
+		}
+	}
+	assertStatus(t, serve(s, http.MethodGet, "/api/v1/orders/", "", "X-User: alice", "X-Forwarded-For: 203.0.113.3"),
+		http.StatusTooManyRequests)
+}
+
+func TestRateLimitByIPTrustsConfiguredProxies(t *testing.T) {
+	s := newTestServer(t, func(config *Config) {
+		config.TrustedProxies = []string{"192.0.2.0/24"}
+		config.Options.EnableRateLimit = true
+		config.RateLimit.Limit = 1
+		config.RateLimit.Key = "ip"
+	})
+	s.RegisterRoute(Route{Method: http.MethodGet, Path: "/ping", Handler: okHandler})
+
+	// httptest requests come from 192.0.2.1, so the forwarded client IP keys the limit
+	for _, ip := range []string{"203.0.113.1", "203.0.113.2"} {
+		assertStatus(t, serve(s, http.MethodGet, "/api/v1/ping", "", "X-Forwarded-For: "+ip), http.StatusOK)
+	}
+	assertStatus(t, serve(s, http.MethodGet, "/api/v1/ping", "", "X-Forwarded-For: 203.0.113.1"), http.StatusTooManyRequests)
+}
+
+func TestNewServerRejectsInvalidTrustedProxies(t *testing.T) {
+	var config Config
+	if err := env.LoadEnv(&config); err != nil {
+		t.Fatal(err)
+	}
+	config.TrustedProxies = []string{"not-an-ip"}
+	if _, err := NewServer(config, zap.NewNop()); err == nil || !strings.Contains(err.Error(), "trusted proxies") {
+		t.Errorf("NewServer error = %v, want invalid trusted proxies", err)
+	}
+}
@@ -19,6 +19,14 @@ type ErrorResponse struct {
 	Type        *string `json:"type,omitempty"`
 	Error       string  `json:"error"`
 	Description string  `json:"description,omitempty"`
+	// Details lists the invalid fields of a request that failed validation
+	Details []FieldError `json:"details,omitempty"`
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule,omitempty"`
+	Message string `json:"message"`
 }
 
 func NewResponse() *Response {
@@ -60,6 +68,14 @@ func (r *Response) SetErrorDescription(description string) *Response {
 	return r
 }
 
+func (r *Response) SetErrorDetails(details []FieldError) *Response {
+	if r.Error == nil {
+		r.Error = &ErrorResponse{}
+	}
+	r.Error.Details = details
+	return r
+}
+
 func (r *Response) SetError(error error, statusCode int) *Response {
 	return r.SetErrorString(error.Error(), statusCode)
 }
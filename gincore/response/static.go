@@ -65,3 +65,15 @@ func TooManyRequests(c *gin.Context) {
 func UnprocessableEntity(c *gin.Context, message string) {
 	NewResponse().SetErrorString(message, http.StatusUnprocessableEntity).Respond(c)
 }
+
+func ValidationFailed(c *gin.Context, details []FieldError) {
+	NewResponse().SetErrorString("Validation Failed", http.StatusUnprocessableEntity).SetErrorDetails(details).Respond(c)
+}
+
+func PayloadTooLarge(c *gin.Context) {
+	NewResponse().SetErrorString("Payload Too Large", http.StatusRequestEntityTooLarge).Respond(c)
+}
+
+func ServiceUnavailable(c *gin.Context) {
+	NewResponse().SetErrorString("Service Unavailable", http.StatusServiceUnavailable).Respond(c)
+}
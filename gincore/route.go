@@ -0,0 +1,287 @@
+package gincore
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nk-bm/gocore/gincore/ginmw"
+	"go.uber.org/zap"
+)
+
+const (
+	// AuthNone opts a route out of the auth provider of its group
+	AuthNone = "none"
+	// AuthBearer and AuthTMA are the provider names documented as the Bearer JWT of ginmw.AuthMW
+	// and the X-TMA-Token of ginmw.TelegramMiniAppAuthMW in the OpenAPI spec
+	AuthBearer = "bearer"
+	AuthTMA    = "tma"
+	// RateLimitNone opts a route out of rate limiting
+	RateLimitNone = "none"
+)
+
+// RouteMeta describes the requirements of a route. Empty fields are inherited from the group
+type RouteMeta struct {
+	// Auth is the name of an auth provider registered with Server.RegisterAuthProvider
+	Auth string `json:"auth,omitempty"`
+	// RateLimitClass selects the rate limit applied to the route, RateLimitNone disables it
+	RateLimitClass string            `json:"rate_limit_class,omitempty"`
+	Extra          map[string]string `json:"extra,omitempty"`
+}
+
+type Route struct {
+	Method  string
+	Path    string
+	Handler gin.HandlerFunc
+	// Middleware runs after the group middleware and the auth provider
+	Middleware []gin.HandlerFunc
+	Name       string
+	Tags       []string
+	Meta       RouteMeta
+	// MaxBodySize and Timeout replace the limits of the server and the groups, as in RouteGroup
+	MaxBodySize int64
+	Timeout     time.Duration
+
+	// Summary, Description and Deprecated document the route in the OpenAPI spec
+	Summary     string
+	Description string
+	Deprecated  bool
+	// RequestType and ResponseType are set by NewRoute and describe the route in the OpenAPI spec
+	RequestType  reflect.Type
+	ResponseType reflect.Type
+}
+
+// NewRoute returns a route serving fn through Handle, documented by the Req and Resp types
+func NewRoute[Req, Resp any](method, path string, fn HandlerFunc[Req, Resp]) Route {
+	return Route{
+		Method:       method,
+		Path:         path,
+		Handler:      Handle(fn),
+		RequestType:  reflect.TypeFor[Req](),
+		ResponseType: reflect.TypeFor[Resp](),
+	}
+}
+
+// RouteGroup registers routes under a common prefix with shared middleware, tags and metadata
+type RouteGroup struct {
+	Prefix     string
+	Middleware []gin.HandlerFunc
+	Tags       []string
+	Meta       RouteMeta
+	// CORS replaces the server CORS config for paths under the group when EnableCORS is on
+	CORS *ginmw.CORSConfig
+	// MaxBodySize and Timeout replace the limits of the server and the enclosing groups,
+	// 0 keeps them and a negative value removes them
+	MaxBodySize int64
+	Timeout     time.Duration
+	Routes      []Route
+	Groups      []RouteGroup
+}
+
+// RouteInfo is an entry of the route table
+type RouteInfo struct {
+	Method  string    `json:"method"`
+	Path    string    `json:"path"`
+	Name    string    `json:"name,omitempty"`
+	Tags    []string  `json:"tags,omitempty"`
+	Meta    RouteMeta `json:"meta"`
+	Handler string    `json:"handler"`
+
+	Summary      string       `json:"summary,omitempty"`
+	Description  string       `json:"description,omitempty"`
+	Deprecated   bool         `json:"deprecated,omitempty"`
+	RequestType  reflect.Type `json:"-"`
+	ResponseType reflect.Type `json:"-"`
+}
+
+// RegisterAuthProvider makes middleware available to routes by name through RouteMeta.Auth
+func (s *Server) RegisterAuthProvider(name string, middleware gin.HandlerFunc) {
+	if s.authProviders == nil {
+		s.authProviders = make(map[string]gin.HandlerFunc)
+	}
+	s.authProviders[name] = middleware
+}
+
+// RegisterGroup registers the group and its nested groups on APIRouter.
+// It panics if a route requires an unknown auth provider or a group has an invalid CORS config,
+// like gin does for conflicting routes
+func (s *Server) RegisterGroup(group RouteGroup) {
+	s.registerGroup(s.APIRouter, group, routeScope{})
+}
+
+// Routes returns the route table: routes registered through Server first, then routes added to gin directly
+func (s *Server) Routes() []RouteInfo {
+	routes := make([]RouteInfo, 0, len(s.routes))
+	routes = append(routes, s.routes...)
+
+	registered := make(map[string]bool, len(s.routes))
+	for _, route := range s.routes {
+		registered[route.Method+" "+route.Path] = true
+	}
+	var other []RouteInfo
+	for _, route := range s.Router.Routes() {
+		if registered[route.Method+" "+route.Path] {
+			continue
+		}
+		other = append(other, RouteInfo{Method: route.Method, Path: route.Path, Handler: route.Handler})
+	}
+	sort.Slice(other, func(i, j int) bool {
+		if other[i].Path != other[j].Path {
+			return other[i].Path < other[j].Path
+		}
+		return other[i].Method < other[j].Method
+	})
+	return append(routes, other...)
+}
+
+func (s *Server) logRoutes() {
+	for _, route := range s.Routes() {
+		s.logger.Info("Route",
+			zap.String("method", route.Method),
+			zap.String("path", route.Path),
+			zap.String("name", route.Name),
+			zap.String("auth", route.Meta.Auth),
+			zap.String("rate_limit_class", route.Meta.RateLimitClass),
+		)
+	}
+}
+
+// routeScope carries what routes inherit from the enclosing groups
+type routeScope struct {
+	tags   []string
+	meta   RouteMeta
+	limits routeLimits
+}
+
+// routeLimits are applied by the limit middleware of the server, which runs before group middleware,
+// so that a group can raise a limit as well as lower it
+type routeLimits struct {
+	maxBodySize int64
+	timeout     time.Duration
+}
+
+func (scope routeScope) withLimits(maxBodySize int64, timeout time.Duration) routeScope {
+	if maxBodySize != 0 {
+		scope.limits.maxBodySize = maxBodySize
+	}
+	if timeout != 0 {
+		scope.limits.timeout = timeout
+	}
+	return scope
+}
+
+func (scope routeScope) merge(tags []string, meta RouteMeta) routeScope {
+	merged := routeScope{
+		tags:   append(append([]string(nil), scope.tags...), tags...),
+		meta:   scope.meta,
+		limits: scope.limits,
+	}
+	if meta.Auth != "" {
+		merged.meta.Auth = meta.Auth
+	}
+	if meta.RateLimitClass != "" {
+		merged.meta.RateLimitClass = meta.RateLimitClass
+	}
+	if len(meta.Extra) > 0 {
+		extra := make(map[string]string, len(scope.meta.Extra)+len(meta.Extra))
+		for k, v := range scope.meta.Extra {
+			extra[k] = v
+		}
+		for k, v := range meta.Extra {
+			extra[k] = v
+		}
+		merged.meta.Extra = extra
+	}
+	return merged
+}
+
+func (s *Server) registerGroup(parent *gin.RouterGroup, group RouteGroup, scope routeScope) {
+	router := parent.Group(group.Prefix, group.Middleware...)
+	scope = scope.merge(group.Tags, group.Meta).withLimits(group.MaxBodySize, group.Timeout)
+
+	// Preflight requests do not reach group middleware, so the server CORS middleware applies the override
+	if group.CORS != nil && s.cors != nil {
+		if err := s.cors.Override(router.BasePath(), *group.CORS); err != nil {
+			panic(fmt.Sprintf("group %s has an invalid CORS config: %v", router.BasePath(), err))
+		}
+	}
+
+	for _, route := range group.Routes {
+		s.registerRoute(router, route, scope)
+	}
+	for _, nested := range group.Groups {
+		s.registerGroup(router, nested, scope)
+	}
+}
+
+func (s *Server) registerRoute(router *gin.RouterGroup, route Route, scope routeScope) {
+	scope = scope.merge(route.Tags, route.Meta).withLimits(route.MaxBodySize, route.Timeout)
+
+	handlers := make([]gin.HandlerFunc, 0, len(route.Middleware)+4)
+	// Requests are limited before auth, so that failed attempts are limited too
+	beforeAuth, afterAuth := s.rateLimitMW(route, scope.meta.RateLimitClass)
+	if beforeAuth != nil {
+		handlers = append(handlers, beforeAuth)
+	}
+	if auth := scope.meta.Auth; auth != "" && auth != AuthNone {
+		provider, ok := s.authProviders[auth]
+		if !ok {
+			panic(fmt.Sprintf("route %s %s requires unknown auth provider %q", route.Method, route.Path, auth))
+		}
+		handlers = append(handlers, provider)
+	}
+	// Limits counted per user run after auth, which sets the user
+	if afterAuth != nil {
+		handlers = append(handlers, afterAuth)
+	}
+	// Rejected requests do not claim the key, so that they can be retried. Auth in route.Middleware
+	// would run too late to scope the key, so the key is only applied to routes with an auth provider
+	if idempotency := s.idempotencyMW(route, scope.meta.Auth); idempotency != nil {
+		handlers = append(handlers, idempotency)
+	}
+	handlers = append(handlers, route.Middleware...)
+	handlers = append(handlers, route.Handler)
+	router.Handle(route.Method, route.Path, handlers...)
+	if scope.limits != (routeLimits{}) {
+		if s.routeLimits == nil {
+			s.routeLimits = make(map[string]routeLimits)
+		}
+		s.routeLimits[route.Method+" "+joinPaths(router.BasePath(), route.Path)] = scope.limits
+	}
+
+	s.routes = append(s.routes, RouteInfo{
+		Method:  route.Method,
+		Path:    joinPaths(router.BasePath(), route.Path),
+		Name:    route.Name,
+		Tags:    scope.tags,
+		Meta:    scope.meta,
+		Handler: handlerName(route.Handler),
+
+		Summary:      route.Summary,
+		Description:  route.Description,
+		Deprecated:   route.Deprecated,
+		RequestType:  route.RequestType,
+		ResponseType: route.ResponseType,
+	})
+}
+
+// joinPaths joins paths the way gin does, keeping the trailing slash of relative
+func joinPaths(absolute, relative string) string {
+	if relative == "" {
+		return absolute
+	}
+	joined := path.Join(absolute, relative)
+	if strings.HasSuffix(relative, "/") && !strings.HasSuffix(joined, "/") {
+		return joined + "/"
+	}
+	return joined
+}
+
+func handlerName(handler gin.HandlerFunc) string {
+	return runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name()
+}
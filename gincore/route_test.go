@@ -0,0 +1,155 @@
+package gincore
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nk-bm/gocore/env"
+	"github.com/nk-bm/gocore/gincore/ginmw"
+	"go.uber.org/zap"
+)
+
+// orderMW appends name to the X-Order response header
+func orderMW(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("X-Order", name)
+		c.Next()
+	}
+}
+
+func okHandler(c *gin.Context) {
+	c.Writer.Header().Add("X-Order", "handler")
+	c.String(http.StatusOK, "ok")
+}
+
+func TestRegisterGroupMiddlewareOrder(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.RegisterAuthProvider("test", func(c *gin.Context) {
+		if c.GetHeader("X-User") == "" {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		c.Writer.Header().Add("X-Order", "auth")
+		c.Next()
+	})
+	s.RegisterGroup(RouteGroup{
+		Prefix:     "/orders",
+		Middleware: []gin.HandlerFunc{orderMW("group")},
+		Meta:       RouteMeta{Auth: "test"},
+		Routes: []Route{
+			{Method: http.MethodGet, Path: "/:id", Handler: okHandler, Middleware: []gin.HandlerFunc{orderMW("route")}},
+			{Method: http.MethodGet, Path: "/public", Handler: okHandler, Meta: RouteMeta{Auth: AuthNone}},
+		},
+		Groups: []RouteGroup{{
+			Prefix:     "/admin",
+			Middleware: []gin.HandlerFunc{orderMW("nested")},
+			Routes:     []Route{{Method: http.MethodPost, Path: "/refund", Handler: okHandler}},
+		}},
+	})
+
+	w := serve(s, http.MethodGet, "/api/v1/orders/1", "", "X-User: alice")
+	assertStatus(t, w, http.StatusOK)
+	if got := strings.Join(w.Header().Values("X-Order"), ","); got != "group,auth,route,handler" {
+		t.Errorf("order = %s, want group,auth,route,handler", got)
+	}
+
+	assertStatus(t, serve(s, http.MethodGet, "/api/v1/orders/1", ""), http.StatusUnauthorized)
+	assertStatus(t, serve(s, http.MethodGet, "/api/v1/orders/public", ""), http.StatusOK)
+
+	w = serve(s, http.MethodPost, "/api/v1/orders/admin/refund", "", "X-User: alice")
+	assertStatus(t, w, http.StatusOK)
+	if got := strings.Join(w.Header().Values("X-Order"), ","); got != "group,nested,auth,handler" {
+		t.Errorf("nested order = %s, want group,nested,auth,handler", got)
+	}
+}
+
+func TestRegisterGroupUnknownAuthProvider(t *testing.T) {
+	s := newTestServer(t, nil)
+	defer func() {
+		if recover() == nil {
+			t.Error("RegisterGroup did not panic on an unknown auth provider")
+		}
+	}()
+	s.RegisterGroup(RouteGroup{Routes: []Route{{Method: http.MethodGet, Path: "/x", Handler: okHandler, Meta: RouteMeta{Auth: "missing"}}}})
+}
+
+func TestRoutesTable(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.RegisterAuthProvider(AuthBearer, okHandler)
+	s.RegisterGroup(RouteGroup{
+		Prefix: "/orders",
+		Tags:   []string{"orders"},
+		Meta:   RouteMeta{Auth: AuthBearer, Extra: map[string]string{"owner": "billing", "tier": "1"}},
+		Routes: []Route{{
+			Method:  http.MethodGet,
+			Path:    "/:id/",
+			Name:    "get-order",
+			Tags:    []string{"read"},
+			Meta:    RouteMeta{RateLimitClass: "strict", Extra: map[string]string{"tier": "2"}},
+			Handler: okHandler,
+		}},
+	})
+	s.Router.GET("/direct", okHandler)
+
+	routes := s.Routes()
+	index := slices.IndexFunc(routes, func(r RouteInfo) bool { return r.Name == "get-order" })
+	if index != 0 {
+		t.Fatalf("registered route at %d, want first in %+v", index, routes)
+	}
+	route := routes[index]
+	if route.Method != http.MethodGet || route.Path != "/api/v1/orders/:id/" {
+		t.Errorf("route = %s %s", route.Method, route.Path)
+	}
+	if !slices.Equal(route.Tags, []string{"orders", "read"}) {
+		t.Errorf("tags = %v, want inherited and own tags", route.Tags)
+	}
+	if route.Meta.Auth != AuthBearer || route.Meta.RateLimitClass != "strict" ||
+		route.Meta.Extra["owner"] != "billing" || route.Meta.Extra["tier"] != "2" {
+		t.Errorf("meta = %+v", route.Meta)
+	}
+	if !strings.HasSuffix(route.Handler, "gincore.okHandler") {
+		t.Errorf("handler = %s", route.Handler)
+	}
+	if !slices.ContainsFunc(routes, func(r RouteInfo) bool { return r.Path == "/direct" }) {
+		t.Errorf("routes added to gin directly are missing: %+v", routes)
+	}
+}
+
+func TestRoutesHandler(t *testing.T) {
+	s := newTestServer(t, func(config *Config) {
+		config.Options.EnableRoutesHandler = true
+		config.AdminToken = "secret"
+	})
+	s.RegisterRoutes([]Route{{Method: http.MethodGet, Path: "/ping", Name: "ping", Handler: okHandler}})
+
+	assertStatus(t, serve(s, http.MethodGet, "/admin/routes", ""), http.StatusUnauthorized)
+	w := serve(s, http.MethodGet, "/admin/routes", "", "Authorization: Bearer secret")
+	assertStatus(t, w, http.StatusOK)
+	if !strings.Contains(w.Body.String(), `"name":"ping"`) {
+		t.Errorf("body = %s, want the ping route", w.Body.String())
+	}
+}
+
+func TestNewServerRejectsInvalidCORSConfig(t *testing.T) {
+	var config Config
+	if err := env.LoadEnv(&config); err != nil {
+		t.Fatal(err)
+	}
+	config.CORS.AllowCredentials = true
+	if _, err := NewServer(config, zap.NewNop()); err == nil {
+		t.Error("NewServer accepted the * origin with credentials")
+	}
+}
+
+func TestRegisterGroupInvalidCORSOverride(t *testing.T) {
+	s := newTestServer(t, nil)
+	defer func() {
+		if recover() == nil {
+			t.Error("RegisterGroup did not panic on an invalid CORS config")
+		}
+	}()
+	s.RegisterGroup(RouteGroup{Prefix: "/partner", CORS: &ginmw.CORSConfig{AllowOrigins: []string{"regex:("}}})
+}
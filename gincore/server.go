@@ -0,0 +1,289 @@
+package gincore
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// HTTPConfig limits how long clients may take, protecting the server from slow clients
+type HTTPConfig struct {
+	ReadTimeout       time.Duration `env:"GIN_READ_TIMEOUT; default:30s"`
+	ReadHeaderTimeout time.Duration `env:"GIN_READ_HEADER_TIMEOUT; default:10s"`
+	WriteTimeout      time.Duration `env:"GIN_WRITE_TIMEOUT; default:60s"`
+	IdleTimeout       time.Duration `env:"GIN_IDLE_TIMEOUT; default:120s"`
+	MaxHeaderBytes    int           `env:"GIN_MAX_HEADER_BYTES; default:1048576"`
+	ShutdownTimeout   time.Duration `env:"GIN_SHUTDOWN_TIMEOUT; default:30s"`
+}
+
+// LimitsConfig bounds requests, route groups and routes replace the limits with their MaxBodySize and Timeout
+type LimitsConfig struct {
+	// MaxBodySize is the largest request body in bytes, 0 disables the limit
+	MaxBodySize int64 `env:"GIN_MAX_BODY_SIZE; default:0"`
+	// RequestTimeout cancels the request context, 0 disables it
+	RequestTimeout time.Duration `env:"GIN_REQUEST_TIMEOUT; default:0s"`
+}
+
+// maxBodySize returns the body limit of the matched route
+func (s *Server) maxBodySize(c *gin.Context) int64 {
+	if limits, ok := s.routeLimits[c.Request.Method+" "+c.FullPath()]; ok && limits.maxBodySize != 0 {
+		return limits.maxBodySize
+	}
+	return s.config.Limits.MaxBodySize
+}
+
+// requestTimeout returns the timeout of the matched route
+func (s *Server) requestTimeout(c *gin.Context) time.Duration {
+	if limits, ok := s.routeLimits[c.Request.Method+" "+c.FullPath()]; ok && limits.timeout != 0 {
+		return limits.timeout
+	}
+	return s.config.Limits.RequestTimeout
+}
+
+// TLSConfig enables HTTPS when both CertFile and KeyFile are set.
+// The files are re-read when they change, so rotated certificates apply without a restart
+type TLSConfig struct {
+	CertFile string `env:"GIN_TLS_CERT_FILE"`
+	KeyFile  string `env:"GIN_TLS_KEY_FILE"`
+	// ReloadInterval is how often the files are checked for changes
+	ReloadInterval time.Duration `env:"GIN_TLS_RELOAD_INTERVAL; default:1m"`
+}
+
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" && c.KeyFile != ""
+}
+
+// Validate fails when only one of the files is set, rather than serving plain HTTP
+func (c TLSConfig) Validate() error {
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return fmt.Errorf("GIN_TLS_CERT_FILE and GIN_TLS_KEY_FILE must be set together")
+	}
+	return nil
+}
+
+// Start listens on the configured Unix socket or host and port and serves in the background.
+// It returns once the server is listening; use Errors to learn when it stops and Shutdown to stop it
+func (s *Server) Start() error {
+	listener, err := s.listen()
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
+
+// Serve serves on an existing listener in the background, it takes ownership of the listener
+func (s *Server) Serve(listener net.Listener) error {
+	if s.httpServer != nil {
+		return fmt.Errorf("server is already started")
+	}
+
+	var handler http.Handler = s.Router
+	if s.config.Options.EnableH2C && !s.config.TLS.Enabled() {
+		handler = h2c.NewHandler(handler, &http2.Server{IdleTimeout: s.config.HTTP.IdleTimeout})
+	}
+
+	httpServer := &http.Server{
+		Handler:           handler,
+		ReadTimeout:       s.config.HTTP.ReadTimeout,
+		ReadHeaderTimeout: s.config.HTTP.ReadHeaderTimeout,
+		WriteTimeout:      s.config.HTTP.WriteTimeout,
+		IdleTimeout:       s.config.HTTP.IdleTimeout,
+		MaxHeaderBytes:    s.config.HTTP.MaxHeaderBytes,
+		ErrorLog:          zap.NewStdLog(s.logger),
+	}
+
+	serve := httpServer.Serve
+	if s.config.TLS.Enabled() {
+		certificates, err := newCertificateReloader(s.config.TLS, s.logger)
+		if err != nil {
+			listener.Close()
+			return err
+		}
+		httpServer.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: certificates.GetCertificate,
+		}
+		serve = func(l net.Listener) error {
+			return httpServer.ServeTLS(l, "", "")
+		}
+	}
+
+	if err := s.startMetricsServer(); err != nil {
+		listener.Close()
+		return err
+	}
+
+	s.httpServer = httpServer
+	s.listener = listener
+	s.errors = make(chan error, 1)
+
+	go func() {
+		err := serve(listener)
+		if errors.Is(err, http.ErrServerClosed) {
+			err = nil
+		}
+		s.errors <- err
+		close(s.errors)
+	}()
+
+	s.logRoutes()
+	s.logger.Info("Server started",
+		zap.String("addr", listener.Addr().String()),
+		zap.Bool("tls", s.config.TLS.Enabled()),
+		zap.Bool("h2c", s.config.Options.EnableH2C && !s.config.TLS.Enabled()),
+	)
+	return nil
+}
+
+// Errors delivers the result of serving once the server stops: nil after Shutdown, the error otherwise
+func (s *Server) Errors() <-chan error {
+	return s.errors
+}
+
+// Addr returns the address the server listens on, or nil if it is not started
+func (s *Server) Addr() net.Addr {
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// Shutdown stops accepting connections and waits for active requests until ctx is done
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.health.SetShuttingDown()
+	if s.httpServer == nil {
+		return nil
+	}
+	s.logger.Info("Server shutting down")
+	if delay := s.config.Health.ShutdownDelay; delay > 0 {
+		// Requests keep being served while load balancers notice the failed readiness probe
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+		}
+	}
+	var metricsErr error
+	if s.metricsServer != nil {
+		metricsErr = s.metricsServer.Shutdown(ctx)
+	}
+	return errors.Join(s.httpServer.Shutdown(ctx), metricsErr)
+}
+
+// ShutdownTimeout is how long Shutdown is given by the app on termination
+func (s *Server) ShutdownTimeout() time.Duration {
+	return s.config.HTTP.ShutdownTimeout
+}
+
+func (s *Server) listen() (net.Listener, error) {
+	if s.config.UnixSocket != "" {
+		if err := removeStaleSocket(s.config.UnixSocket); err != nil {
+			return nil, err
+		}
+		return net.Listen("unix", s.config.UnixSocket)
+	}
+
+	if s.config.Port == 0 {
+		return nil, fmt.Errorf("port is not set")
+	}
+	return net.Listen("tcp", net.JoinHostPort(s.config.Host, fmt.Sprint(s.config.Port)))
+}
+
+// removeStaleSocket removes a socket file left by a previous process, which would make Listen fail.
+// Other files are kept, the path is likely misconfigured
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("stat unix socket: %w", err)
+	}
+	if info.Mode().Type() != os.ModeSocket {
+		return fmt.Errorf("unix socket path %s exists and is not a socket", path)
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("remove unix socket: %w", err)
+	}
+	return nil
+}
+
+// certificateReloader serves the key pair from disk and reloads it after the files change
+type certificateReloader struct {
+	config TLSConfig
+	logger *zap.Logger
+
+	mu            sync.Mutex
+	certificate   *tls.Certificate
+	loadedModTime time.Time
+	checkedAt     time.Time
+}
+
+func newCertificateReloader(config TLSConfig, logger *zap.Logger) (*certificateReloader, error) {
+	r := &certificateReloader{config: config, logger: logger}
+	modTime, err := r.filesModTime()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.load(modTime); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *certificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.checkedAt) >= r.config.ReloadInterval {
+		r.checkedAt = time.Now()
+		modTime, err := r.filesModTime()
+		if err != nil {
+			r.logger.Error("Failed to check TLS certificate", zap.Error(err))
+		} else if modTime.After(r.loadedModTime) {
+			// The previous certificate stays in use if the new one cannot be loaded
+			if err := r.load(modTime); err != nil {
+				r.logger.Error("Failed to reload TLS certificate", zap.Error(err))
+			} else {
+				r.logger.Info("TLS certificate reloaded", zap.String("cert_file", r.config.CertFile))
+			}
+		}
+	}
+	return r.certificate, nil
+}
+
+// filesModTime returns the latest modification time of the certificate and key files
+func (r *certificateReloader) filesModTime() (time.Time, error) {
+	var latest time.Time
+	for _, path := range []string{r.config.CertFile, r.config.KeyFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("stat %s: %w", path, err)
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+func (r *certificateReloader) load(modTime time.Time) error {
+	certificate, err := tls.LoadX509KeyPair(r.config.CertFile, r.config.KeyFile)
+	if err != nil {
+		return fmt.Errorf("load TLS certificate: %w", err)
+	}
+	r.certificate = &certificate
+	r.loadedModTime = modTime
+	r.checkedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,253 @@
+package gincore
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nk-bm/gocore/env"
+)
+
+// startTestServer serves s on a random local port and shuts it down after the test
+func startTestServer(t *testing.T, s *Server) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Serve(listener); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = s.Shutdown(context.Background()) })
+	return s.Addr().String()
+}
+
+func TestServerServeAndShutdown(t *testing.T) {
+	s := newTestServer(t, nil)
+	started := make(chan struct{})
+	s.Router.GET("/slow", func(c *gin.Context) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		c.String(http.StatusOK, "done")
+	})
+	addr := startTestServer(t, s)
+
+	if err := s.Serve(nil); err == nil {
+		t.Error("second Serve succeeded, want an error")
+	}
+
+	result := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr + "/slow")
+		if err != nil {
+			result <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		result <- string(body)
+	}()
+
+	<-started
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-result; got != "done" {
+		t.Errorf("active request = %q, want it completed during shutdown", got)
+	}
+	if err := <-s.Errors(); err != nil {
+		t.Errorf("Errors() = %v, want nil after Shutdown", err)
+	}
+	if _, err := http.Get("http://" + addr + "/slow"); err == nil {
+		t.Error("server accepts connections after Shutdown")
+	}
+}
+
+func TestServerAppliesHTTPConfig(t *testing.T) {
+	s := newTestServer(t, func(config *Config) {
+		config.HTTP.ReadHeaderTimeout = 100 * time.Millisecond
+	})
+	addr := startTestServer(t, s)
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	// The request line is sent without headers, the server closes the connection after the timeout
+	if _, err := conn.Write([]byte("GET / HTTP/1.1\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := io.ReadAll(conn); err != nil {
+		t.Errorf("connection is not closed by the server: %v", err)
+	}
+}
+
+func TestServerUnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "app.sock")
+	// A stale socket file from a previous process is replaced
+	stale, err := net.ListenUnix("unix", &net.UnixAddr{Name: socket, Net: "unix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.SetUnlinkOnClose(false)
+	stale.Close()
+
+	s := newTestServer(t, func(config *Config) {
+		config.UnixSocket = socket
+	})
+	s.Router.GET("/ping", func(c *gin.Context) { c.String(http.StatusOK, "pong") })
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Shutdown(context.Background())
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+	}}
+	resp, err := client.Get("http://unix/ping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if body, _ := io.ReadAll(resp.Body); string(body) != "pong" {
+		t.Errorf("body = %q, want pong", body)
+	}
+}
+
+func TestServerKeepsNonSocketFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.sock")
+	if err := os.WriteFile(path, []byte("data"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s := newTestServer(t, func(config *Config) {
+		config.UnixSocket = path
+	})
+	if err := s.Start(); err == nil {
+		s.Shutdown(context.Background())
+		t.Fatal("Start succeeded on a regular file")
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "data" {
+		t.Errorf("file = %q, %v, want it kept", data, err)
+	}
+}
+
+func TestTLSConfigValidate(t *testing.T) {
+	for _, config := range []TLSConfig{{CertFile: "cert.pem"}, {KeyFile: "key.pem"}} {
+		if err := config.Validate(); err == nil {
+			t.Errorf("Validate(%+v) succeeded, want an error", config)
+		}
+	}
+	for _, config := range []TLSConfig{{}, {CertFile: "cert.pem", KeyFile: "key.pem"}} {
+		if err := config.Validate(); err != nil {
+			t.Errorf("Validate(%+v) = %v", config, err)
+		}
+	}
+
+	var config Config
+	if err := env.LoadEnv(&config); err != nil {
+		t.Fatal(err)
+	}
+	config.TLS.CertFile = "cert.pem"
+	if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "GIN_TLS_KEY_FILE") {
+		t.Errorf("Config.Validate() = %v, want the TLS error", err)
+	}
+}
+
+func TestServerTLSReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeTestCertificate(t, certFile, keyFile, "first")
+
+	s := newTestServer(t, func(config *Config) {
+		config.TLS = TLSConfig{CertFile: certFile, KeyFile: keyFile, ReloadInterval: time.Nanosecond}
+	})
+	s.Router.GET("/ping", func(c *gin.Context) { c.String(http.StatusOK, "pong") })
+	addr := startTestServer(t, s)
+
+	if got := serverCertificateName(t, addr); got != "first" {
+		t.Fatalf("certificate = %q, want first", got)
+	}
+
+	writeTestCertificate(t, certFile, keyFile, "second")
+	later := time.Now().Add(time.Second)
+	for _, path := range []string{certFile, keyFile} {
+		if err := os.Chtimes(path, later, later); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := serverCertificateName(t, addr); got != "second" {
+		t.Errorf("certificate = %q, want the reloaded second", got)
+	}
+}
+
+func TestServerRejectsMissingCertificate(t *testing.T) {
+	s := newTestServer(t, func(config *Config) {
+		config.TLS = TLSConfig{CertFile: "missing.pem", KeyFile: "missing.key"}
+	})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Serve(listener); err == nil {
+		s.Shutdown(context.Background())
+		t.Fatal("Serve succeeded without a certificate")
+	}
+}
+
+func serverCertificateName(t *testing.T, addr string) string {
+	t.Helper()
+	conn, err := tls.Dial("tcp", addr, &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates[0].Subject.CommonName
+}
+
+func writeTestCertificate(t *testing.T, certFile, keyFile, name string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
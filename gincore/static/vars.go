@@ -3,4 +3,10 @@ package static
 const (
 	TMA_INIT_DATA = "TMA_INIT_DATA"
 	TMA_TOKEN_KEY = "X-TMA-Token"
+
+	LOGGER     = "LOGGER"
+	REQUEST_ID = "REQUEST_ID"
+
+	REQUEST_ID_HEADER  = "X-Request-ID"
+	TRACEPARENT_HEADER = "traceparent"
 )
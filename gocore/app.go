@@ -1,40 +1,79 @@
 package gocore
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"os/signal"
+	"slices"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/nk-bm/gocore/dbcore"
 	"github.com/nk-bm/gocore/env"
 	"github.com/nk-bm/gocore/gincore"
+	"github.com/nk-bm/gocore/gincore/health"
+	"github.com/nk-bm/gocore/goutils"
 	"go.uber.org/zap"
 )
 
 type AppOptions struct {
 	Logger              *zap.Logger
 	DisableGlobalLogger bool
-	DisableMigrations   bool
-	DBTablePrefix       string
+	// DisableLogBridge keeps log/slog, the log package and gin writers out of the zap pipeline
+	DisableLogBridge  bool
+	DisableMigrations bool
+	DBTablePrefix     string
+	EnvOptions        env.LoadOptions
 }
 
 type AppConfig struct {
 	IsProd         bool `env:"PRODUCTION; default:false"`
+	LoggerConfig   LoggerConfig
+	Tracing        TracingConfig
 	PostgresConfig dbcore.PostgresConfig
 	GinConfig      gincore.Config
-	Options        AppOptions
+	// Admin serves the operational endpoints on a separate internal address instead of the main server
+	Admin   gincore.AdminConfig
+	Options AppOptions
+}
+
+// Validate is called by env.Watcher before a reloaded config is applied
+func (c *AppConfig) Validate() error {
+	return errors.Join(c.LoggerConfig.Validate(), c.Tracing.Validate(), c.GinConfig.Validate(), c.Admin.Validate())
 }
 
 type App struct {
 	Name      string
 	GinServer *gincore.Server
-	Postgres  *dbcore.PostgresClient
-	Migrator  *dbcore.Migrator
+	// AdminServer is nil when the admin server is disabled
+	AdminServer *gincore.AdminServer
+	Postgres    *dbcore.PostgresClient
+	Migrator    *dbcore.Migrator
+	// Tracing is nil when traces are not exported
+	Tracing *Tracing
+
+	ConfigWatcher *env.Watcher[AppConfig]
 
 	L *zap.Logger
 }
 
 func NewDefaultApp(name string) (*App, error) {
+	watcher, err := LoadDefaultConfig()
+	if err != nil {
+		return nil, err
+	}
+	return NewDefaultAppWithWatcher(name, watcher)
+}
+
+// LoadDefaultConfig loads AppConfig from the environment, .env and the encrypted secrets file
+// as NewDefaultApp does. The returned watcher is started by App.Start
+func LoadDefaultConfig() (*env.Watcher[AppConfig], error) {
+	// Variables of the real environment take precedence over .env, as with godotenv.Load
+	envSnapshot := env.EnvSnapshot()
 	if err := godotenv.Load(); err != nil {
 		// Ignore error if .env file doesn't exist
 		if !strings.Contains(err.Error(), "no such file") {
@@ -42,12 +81,44 @@ func NewDefaultApp(name string) (*App, error) {
 		}
 	}
 
-	var config AppConfig
-	if err := env.LoadEnv(&config); err != nil {
+	secretProvider, err := env.NewEncryptedFileProviderFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("error loading secrets file: %w", err)
+	}
+
+	loadOptions := env.LoadOptions{Sources: []env.Source{envSnapshot}}
+	if secretProvider != nil {
+		loadOptions.SecretProvider = secretProvider
+	}
+
+	// .env is re-read on reload, so its changes are applied unless overridden by the real environment
+	watcherOptions := env.WatcherOptions{
+		LoadOptions:  loadOptions,
+		PollInterval: env.GetDuration("ENV_WATCH_INTERVAL", 10*time.Second),
+		OnError: func(err error) {
+			zap.L().Error("Config reload failed", zap.Error(err))
+		},
+	}
+	if _, err := os.Stat(".env"); err == nil {
+		watcherOptions.Files = []string{".env"}
+	}
+
+	return env.NewWatcher[AppConfig](watcherOptions)
+}
+
+// NewDefaultAppWithWatcher creates the app from the config of a watcher returned by LoadDefaultConfig
+// and subscribes it to reloads
+func NewDefaultAppWithWatcher(name string, watcher *env.Watcher[AppConfig]) (*App, error) {
+	config := *watcher.Current()
+	config.Options.EnvOptions = watcher.LoadOptions()
+
+	app, err := NewApp(name, config, []dbcore.Migration{})
+	if err != nil {
 		return nil, err
 	}
 
-	return NewApp(name, config, []dbcore.Migration{})
+	app.WatchConfig(watcher)
+	return app, nil
 }
 
 func NewApp(appName string, config AppConfig, migrations []dbcore.Migration) (*App, error) {
@@ -55,17 +126,34 @@ func NewApp(appName string, config AppConfig, migrations []dbcore.Migration) (*A
 		return nil, fmt.Errorf("service name cannot contain spaces")
 	}
 
+	buildInfo := goutils.GetBuildInfo()
 	if !config.Options.DisableGlobalLogger {
 		if config.Options.Logger == nil {
-			InitGlobalLogger(config.IsProd)
+			if config.LoggerConfig.ServiceName == "" {
+				config.LoggerConfig.ServiceName = appName
+			}
+			if err := InitGlobalLoggerWithConfig(config.LoggerConfig, config.IsProd); err != nil {
+				return nil, fmt.Errorf("init logger: %w", err)
+			}
 			config.Options.Logger = zap.L()
 		} else {
 			SetGlobalLogger(config.Options.Logger)
 		}
+		if !config.Options.DisableLogBridge {
+			RedirectStdLogs()
+		}
 	}
 
 	L := config.Options.Logger
-	L.Info("Initializing core components...", zap.String("app_name", appName))
+	L.Info("Initializing core components...",
+		zap.String("app_name", appName),
+		zap.String("version", buildInfo.Version),
+		zap.String("commit", buildInfo.Commit),
+		zap.String("build_time", buildInfo.BuildTime),
+		zap.String("commit_time", buildInfo.CommitTime),
+		zap.String("go_version", buildInfo.GoVersion),
+	)
+	L.Debug("Loaded configuration", zap.Any("config", env.Dump(&config)))
 
 	defer func() {
 		if r := recover(); r != nil {
@@ -73,39 +161,179 @@ func NewApp(appName string, config AppConfig, migrations []dbcore.Migration) (*A
 		}
 	}()
 
-	postgres := dbcore.NewPostgresClient(config.PostgresConfig)
+	// The tracer provider must be installed before spans are started by the database and the server
+	if config.Tracing.ServiceVersion == "" {
+		config.Tracing.ServiceVersion = buildInfo.Version
+	}
+	tracing, err := InitTracing(config.Tracing, appName)
+	if err != nil {
+		return nil, fmt.Errorf("init tracing: %w", err)
+	}
+
+	postgres := dbcore.NewPostgresClientWithLogger(config.PostgresConfig, Logger(LoggerDB))
 	if err := postgres.Connect(); err != nil {
 		return nil, err
 	}
 	var migrator *dbcore.Migrator
 	if !config.Options.DisableMigrations {
-		migrator = dbcore.NewMigrator(postgres.GormDB(), config.Options.Logger, config.Options.DBTablePrefix, migrations)
+		migrator = dbcore.NewMigrator(postgres.GormDB(), Logger(LoggerMigrator), config.Options.DBTablePrefix, migrations)
 		if err := migrator.Run(); err != nil {
 			return nil, err
 		}
 	}
 
-	ginServer := gincore.NewServer(config.GinConfig, config.Options.Logger)
+	config.GinConfig.AdminServer = config.Admin.Enabled
+	ginServer, err := gincore.NewServer(config.GinConfig, Logger(LoggerHTTP))
+	if err != nil {
+		return nil, fmt.Errorf("create server: %w", err)
+	}
+	if config.GinConfig.Options.EnableRateLimit && config.GinConfig.RateLimit.Store == "postgres" {
+		// With DisableMigrations the table is created where the service migrations are run
+		if !config.Options.DisableMigrations {
+			rateLimitMigrator := dbcore.NewRateLimitMigrator(postgres.GormDB(), Logger(LoggerMigrator), config.Options.DBTablePrefix)
+			if err := rateLimitMigrator.Run(); err != nil {
+				return nil, fmt.Errorf("migrate rate limit table: %w", err)
+			}
+		}
+		ginServer.SetRateLimitStore(dbcore.NewPostgresRateLimitStore(postgres.GormDB(), config.Options.DBTablePrefix))
+	}
+	if config.GinConfig.Options.EnableIdempotency && config.GinConfig.Idempotency.Store == "postgres" {
+		if !config.Options.DisableMigrations {
+			idempotencyMigrator := dbcore.NewIdempotencyMigrator(postgres.GormDB(), Logger(LoggerMigrator), config.Options.DBTablePrefix)
+			if err := idempotencyMigrator.Run(); err != nil {
+				return nil, fmt.Errorf("migrate idempotency table: %w", err)
+			}
+		}
+		ginServer.SetIdempotencyStore(dbcore.NewPostgresIdempotencyStore(postgres.GormDB(), config.Options.DBTablePrefix))
+	}
+	if registry := ginServer.Metrics(); registry != nil && !config.GinConfig.Options.DisableDBMetrics {
+		if err := registry.Register(postgres.Collector()); err != nil {
+			return nil, fmt.Errorf("register database metrics: %w", err)
+		}
+		if migrator != nil {
+			if err := registry.Register(migrator.Collector()); err != nil {
+				return nil, fmt.Errorf("register migration metrics: %w", err)
+			}
+		}
+	}
+	ginServer.Health().MustRegister(health.Check{
+		Name:   "postgres",
+		Check:  postgres.Ping,
+		Probes: []health.Probe{health.Readiness, health.Startup},
+	})
+	if migrator != nil {
+		ginServer.Health().MustRegister(health.Check{
+			Name:   "migrations",
+			Check:  migrator.HealthCheck,
+			Probes: []health.Probe{health.Readiness, health.Startup},
+		})
+	}
+	ginServer.RegisterConfigHandler(&config, config.Options.EnvOptions)
+	ginServer.RegisterLogLevelHandlers(Levels())
+
+	var adminServer *gincore.AdminServer
+	if config.Admin.Enabled {
+		adminServer, err = gincore.NewAdminServer(config.Admin, ginServer, Logger(LoggerHTTP))
+		if err != nil {
+			return nil, fmt.Errorf("create admin server: %w", err)
+		}
+		adminServer.RegisterConfigHandler(&config, config.Options.EnvOptions)
+		adminServer.RegisterLogLevelHandlers(Levels())
+	}
 
 	L.Info("Core components initialized", zap.String("app_name", appName))
 	return &App{
-		Name:      appName,
-		GinServer: ginServer,
-		Postgres:  postgres,
-		Migrator:  migrator,
-		L:         config.Options.Logger,
+		Name:        appName,
+		GinServer:   ginServer,
+		AdminServer: adminServer,
+		Postgres:    postgres,
+		Migrator:    migrator,
+		Tracing:     tracing,
+		L:           config.Options.Logger,
 	}, nil
 }
 
+// WatchConfig subscribes the app to config reloads. The watcher is started by Start
+func (s *App) WatchConfig(watcher *env.Watcher[AppConfig]) {
+	s.ConfigWatcher = watcher
+	watcher.Subscribe(func(old, new *AppConfig) {
+		oldLogger, newLogger := old.LoggerConfig, new.LoggerConfig
+		if oldLogger.Level != newLogger.Level || !slices.Equal(oldLogger.ModuleLevels, newLogger.ModuleLevels) {
+			if err := SetLogLevels(newLogger.Level, newLogger.ModuleLevels); err != nil {
+				s.L.Error("Failed to change log level", zap.String("level", newLogger.Level), zap.Error(err))
+				return
+			}
+			s.L.Info("Log level changed", zap.String("level", newLogger.Level), zap.Strings("module_levels", newLogger.ModuleLevels))
+		}
+	})
+}
+
+// Start runs the app until SIGINT or SIGTERM is received or the server fails, then shuts it down gracefully
 func (s *App) Start() error {
 	s.L.Info("Starting core components...")
-	s.L.Info("Running migrations...")
-	if err := s.Migrator.Run(); err != nil {
-		return err
+	if s.ConfigWatcher != nil {
+		if err := s.ConfigWatcher.Start(); err != nil {
+			return err
+		}
 	}
 
-	s.L.Info("Migrations completed")
+	if s.Migrator != nil {
+		s.L.Info("Running migrations...")
+		if err := s.Migrator.Run(); err != nil {
+			return err
+		}
+		s.L.Info("Migrations completed")
+	}
 
 	s.L.Info("Starting Gin server...")
-	return s.GinServer.Start()
+	if err := s.GinServer.Start(); err != nil {
+		return err
+	}
+	if s.AdminServer != nil {
+		if err := s.AdminServer.Start(); err != nil {
+			return errors.Join(err, s.GinServer.Shutdown(context.Background()))
+		}
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	var serveErr error
+	select {
+	case sig := <-signals:
+		s.L.Info("Received signal, shutting down", zap.String("signal", sig.String()))
+	case serveErr = <-s.GinServer.Errors():
+		s.L.Error("Gin server stopped", zap.Error(serveErr))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.GinServer.ShutdownTimeout())
+	defer cancel()
+	return errors.Join(serveErr, s.Shutdown(ctx))
+}
+
+// Shutdown stops the server waiting for active requests until ctx is done, then releases the other components
+func (s *App) Shutdown(ctx context.Context) error {
+	var errs []error
+	if err := s.GinServer.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("shutdown gin server: %w", err))
+	}
+	// The admin server stops last, so that probes observe the shutdown
+	if s.AdminServer != nil {
+		if err := s.AdminServer.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("shutdown admin server: %w", err))
+		}
+	}
+	if s.ConfigWatcher != nil {
+		s.ConfigWatcher.Stop()
+	}
+	if err := s.Postgres.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("close postgres: %w", err))
+	}
+	if err := s.Tracing.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("shutdown tracing: %w", err))
+	}
+	s.L.Info("Core components stopped")
+	_ = s.L.Sync()
+	return errors.Join(errs...)
 }
@@ -4,6 +4,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/nk-bm/gocore/gincore/ginmw"
 	"github.com/nk-bm/gocore/goutils"
+	"go.uber.org/zap"
 )
 
 type AuthManager struct {
@@ -25,9 +26,17 @@ func (m *AuthManager) GinMiddleware() gin.HandlerFunc {
 }
 
 func (m *AuthManager) ExtractIDFromToken(token string) (int64, error) {
-	return goutils.ExtractIDFromJWT(m.secretKey, token, m.idKey, m.authType)
+	id, err := goutils.ExtractIDFromJWT(m.secretKey, token, m.idKey, m.authType)
+	if err != nil {
+		Logger(LoggerAuth).Debug("Token rejected", zap.String("auth_type", m.authType), zap.Error(err))
+	}
+	return id, err
 }
 
 func (m *AuthManager) GenerateToken(id int64) (string, error) {
-	return goutils.GenerateJWT(m.secretKey, m.authType, m.idKey, id)
+	token, err := goutils.GenerateJWT(m.secretKey, m.authType, m.idKey, id)
+	if err != nil {
+		Logger(LoggerAuth).Error("Token generation failed", zap.String("auth_type", m.authType), zap.Error(err))
+	}
+	return token, err
 }
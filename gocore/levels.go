@@ -0,0 +1,210 @@
+package gocore
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Names of the loggers that have their own levels
+const (
+	LoggerApp      = "app"
+	LoggerHTTP     = "http"
+	LoggerDB       = "db"
+	LoggerMigrator = "migrator"
+	LoggerAuth     = "auth"
+	// LoggerStdlib receives log/slog and standard log package output
+	LoggerStdlib = "stdlib"
+)
+
+// LevelRegistry keeps a zap.AtomicLevel per named logger
+type LevelRegistry struct {
+	mu      sync.Mutex
+	levels  map[string]zap.AtomicLevel
+	reverts map[string]*levelRevert
+}
+
+type levelRevert struct {
+	timer    *time.Timer
+	previous zapcore.Level
+}
+
+var levels = NewLevelRegistry(LoggerApp, LoggerHTTP, LoggerDB, LoggerMigrator, LoggerAuth, LoggerStdlib)
+
+func NewLevelRegistry(names ...string) *LevelRegistry {
+	r := &LevelRegistry{
+		levels:  make(map[string]zap.AtomicLevel),
+		reverts: make(map[string]*levelRevert),
+	}
+	loggerMu.RLock()
+	defaultLevel := defaultGlobalLevel
+	loggerMu.RUnlock()
+	for _, name := range names {
+		r.levels[name] = zap.NewAtomicLevelAt(defaultLevel)
+	}
+	return r
+}
+
+// Levels returns the registry used by the global logger and module loggers
+func Levels() *LevelRegistry {
+	return levels
+}
+
+// Level returns the level of the named logger, registering it with the app level if unknown
+func (r *LevelRegistry) Level(name string) zap.AtomicLevel {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	level, ok := r.levels[name]
+	if !ok {
+		level = zap.NewAtomicLevelAt(r.levels[LoggerApp].Level())
+		r.levels[name] = level
+	}
+	return level
+}
+
+// All returns the current level of every logger
+func (r *LevelRegistry) All() map[string]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make(map[string]string, len(r.levels))
+	for name, level := range r.levels {
+		result[name] = level.String()
+	}
+	return result
+}
+
+// SetLevel changes the level of the named logger. If ttl is positive the previous level
+// is restored after ttl
+func (r *LevelRegistry) SetLevel(name, level string, ttl time.Duration) error {
+	parsed, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	atomicLevel, ok := r.levels[name]
+	if !ok {
+		return fmt.Errorf("unknown logger %q, expected one of %s", name, strings.Join(r.names(), ", "))
+	}
+
+	previous := atomicLevel.Level()
+	if revert, ok := r.reverts[name]; ok {
+		// A change before the TTL expires reverts to the level before the first change
+		revert.timer.Stop()
+		previous = revert.previous
+		delete(r.reverts, name)
+	}
+	atomicLevel.SetLevel(parsed)
+
+	if ttl > 0 {
+		revert := &levelRevert{previous: previous}
+		revert.timer = time.AfterFunc(ttl, func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			if r.reverts[name] != revert {
+				return
+			}
+			delete(r.reverts, name)
+			atomicLevel.SetLevel(revert.previous)
+		})
+		r.reverts[name] = revert
+	}
+	return nil
+}
+
+// reset sets every logger to level, applies overrides and cancels pending reverts
+func (r *LevelRegistry) reset(level zapcore.Level, overrides map[string]zapcore.Level) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for name, revert := range r.reverts {
+		revert.timer.Stop()
+		delete(r.reverts, name)
+	}
+	for name, atomicLevel := range r.levels {
+		if override, ok := overrides[name]; ok {
+			atomicLevel.SetLevel(override)
+		} else {
+			atomicLevel.SetLevel(level)
+		}
+	}
+	for name, override := range overrides {
+		if _, ok := r.levels[name]; !ok {
+			r.levels[name] = zap.NewAtomicLevelAt(override)
+		}
+	}
+}
+
+func (r *LevelRegistry) names() []string {
+	names := make([]string, 0, len(r.levels))
+	for name := range r.levels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Logger returns a child of the global logger named name and filtered by its own level
+func Logger(name string) *zap.Logger {
+	loggerMu.RLock()
+	base := rootLogger
+	loggerMu.RUnlock()
+	if base == nil {
+		base = zap.L()
+	}
+	if name != LoggerApp {
+		base = base.Named(name)
+	}
+
+	level := levels.Level(name)
+	return base.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &levelFilterCore{Core: core, level: level}
+	}))
+}
+
+// levelFilterCore drops entries below level before they reach the wrapped core
+type levelFilterCore struct {
+	zapcore.Core
+	level zapcore.LevelEnabler
+}
+
+func (c *levelFilterCore) Enabled(level zapcore.Level) bool {
+	return c.level.Enabled(level) && c.Core.Enabled(level)
+}
+
+func (c *levelFilterCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelFilterCore{Core: c.Core.With(fields), level: c.level}
+}
+
+func (c *levelFilterCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.level.Enabled(entry.Level) {
+		return checked
+	}
+	return c.Core.Check(entry, checked)
+}
+
+// parseModuleLevels parses "name=level" pairs of LOG_MODULE_LEVELS
+func parseModuleLevels(values []string) (map[string]zapcore.Level, error) {
+	result := make(map[string]zapcore.Level, len(values))
+	for _, value := range values {
+		name, level, ok := strings.Cut(value, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid LOG_MODULE_LEVELS entry %q: expected name=level", value)
+		}
+		parsed, err := zapcore.ParseLevel(strings.TrimSpace(level))
+		if err != nil {
+			return nil, fmt.Errorf("invalid LOG_MODULE_LEVELS entry %q: %w", value, err)
+		}
+		result[strings.TrimSpace(name)] = parsed
+	}
+	return result, nil
+}
@@ -0,0 +1,100 @@
+package gocore
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLevelRegistrySetLevel(t *testing.T) {
+	registry := NewLevelRegistry(LoggerApp, LoggerDB)
+	if err := registry.SetLevel(LoggerDB, "debug", 0); err != nil {
+		t.Fatal(err)
+	}
+	if got := registry.Level(LoggerDB).Level(); got != zapcore.DebugLevel {
+		t.Fatalf("db level = %v, want debug", got)
+	}
+	if got := registry.Level(LoggerApp).Level(); got != zapcore.InfoLevel {
+		t.Fatalf("app level = %v, want it unchanged", got)
+	}
+	if err := registry.SetLevel("unknown", "debug", 0); err == nil {
+		t.Fatal("expected an error for an unknown logger")
+	}
+	if err := registry.SetLevel(LoggerDB, "loud", 0); err == nil {
+		t.Fatal("expected an error for an invalid level")
+	}
+}
+
+func TestLevelRegistryRevert(t *testing.T) {
+	registry := NewLevelRegistry(LoggerHTTP)
+	if err := registry.SetLevel(LoggerHTTP, "debug", 20*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	// A change before the TTL expires reverts to the level before the first change
+	if err := registry.SetLevel(LoggerHTTP, "warn", 20*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if got := registry.All()[LoggerHTTP]; got != "warn" {
+		t.Fatalf("http level = %s, want warn", got)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for registry.Level(LoggerHTTP).Level() != zapcore.InfoLevel {
+		if time.Now().After(deadline) {
+			t.Fatalf("http level = %v, want it reverted to info", registry.Level(LoggerHTTP).Level())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestModuleLoggerLevels(t *testing.T) {
+	path := initTestLogger(t, LoggerConfig{Level: "info", ModuleLevels: []string{"db=debug"}, DisableSampling: true})
+
+	Logger(LoggerDB).Debug("db debug")
+	Logger(LoggerHTTP).Debug("http debug")
+	zap.L().Debug("app debug")
+
+	lines := readLogLines(t, path)
+	if len(lines) != 1 || lines[0]["msg"] != "db debug" || lines[0]["logger"] != LoggerDB {
+		t.Fatalf("got %v, want only the db debug line", lines)
+	}
+
+	// SetLogLevel keeps the module overrides
+	if err := SetLogLevel("error"); err != nil {
+		t.Fatal(err)
+	}
+	if got := Levels().Level(LoggerDB).Level(); got != zapcore.DebugLevel {
+		t.Fatalf("db level = %v, want debug", got)
+	}
+	if got := Levels().Level(LoggerHTTP).Level(); got != zapcore.ErrorLevel {
+		t.Fatalf("http level = %v, want error", got)
+	}
+}
+
+// Config reloads change levels while requests create loggers, run with -race
+func TestLogLevelsConcurrentReload(t *testing.T) {
+	initTestLogger(t, LoggerConfig{DisableSampling: true})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = SetLogLevels("debug", []string{"db=warn"})
+				_ = SetLogLevel("")
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				Logger(LoggerDB).Debug("query")
+				SetGlobalLogger(Logger(LoggerApp))
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -1,25 +1,304 @@
 package gocore
 
 import (
+	"fmt"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
+var (
+	// loggerMu guards the variables below: they are replaced by InitGlobalLoggerWithConfig and SetLogLevels,
+	// which config reloads call while other goroutines create loggers
+	loggerMu           sync.RWMutex
+	defaultGlobalLevel = zapcore.InfoLevel
+	moduleLevels       map[string]zapcore.Level
+
+	// rootLogger is the unfiltered logger from which the app and module loggers are derived
+	rootLogger *zap.Logger
+)
+
+// LoggerConfig configures the global logger. Empty values fall back to zap's production
+// or development preset depending on AppConfig.IsProd
+type LoggerConfig struct {
+	Level              string   `env:"LOG_LEVEL; reload"`
+	ModuleLevels       []string `env:"LOG_MODULE_LEVELS; reload"`
+	Encoding           string   `env:"LOG_ENCODING"`
+	OutputPaths        []string `env:"LOG_OUTPUT_PATHS"`
+	OutputLevel        string   `env:"LOG_OUTPUT_LEVEL"`
+	DisableOutput      bool     `env:"LOG_DISABLE_OUTPUT; default:false"`
+	ErrorOutputPaths   []string `env:"LOG_ERROR_OUTPUT_PATHS"`
+	DisableSampling    bool     `env:"LOG_DISABLE_SAMPLING; default:false"`
+	SamplingInitial    int      `env:"LOG_SAMPLING_INITIAL"`
+	SamplingThereafter int      `env:"LOG_SAMPLING_THEREAFTER"`
+	StacktraceLevel    string   `env:"LOG_STACKTRACE_LEVEL"`
+	CallerSkip         int      `env:"LOG_CALLER_SKIP; default:0"`
+	// TimeFormat is one of iso8601, rfc3339, rfc3339nano, epoch, epochmillis, epochnanos or a Go time layout
+	TimeFormat string `env:"LOG_TIME_FORMAT"`
+
+	// Redaction masks credentials and PII in every entry, see DefaultRedactionPolicy
+	DisableRedaction bool     `env:"LOG_DISABLE_REDACTION; default:false"`
+	RedactKeys       []string `env:"LOG_REDACT_KEYS"`
+	RedactPatterns   []string `env:"LOG_REDACT_PATTERNS; sep:';;'"`
+
+	// Additional sinks, each with its own level and encoding
+	File      FileSinkConfig   `env:"envPrefix:LOG_FILE_"`
+	ErrorFile FileSinkConfig   `env:"envPrefix:LOG_ERROR_FILE_"`
+	Syslog    SyslogSinkConfig `env:"envPrefix:LOG_SYSLOG_"`
+
+	ServiceName    string `env:"LOG_SERVICE_NAME"`
+	ServiceVersion string `env:"LOG_SERVICE_VERSION"`
+	Environment    string `env:"LOG_ENVIRONMENT"`
+	Hostname       string `env:"LOG_HOSTNAME"`
+}
+
+// Validate checks values that can be validated without building the logger
+func (c LoggerConfig) Validate() error {
+	if c.Level != "" {
+		if _, err := zapcore.ParseLevel(c.Level); err != nil {
+			return fmt.Errorf("invalid LOG_LEVEL: %w", err)
+		}
+	}
+	if _, err := parseModuleLevels(c.ModuleLevels); err != nil {
+		return err
+	}
+	if c.StacktraceLevel != "" {
+		if _, err := zapcore.ParseLevel(c.StacktraceLevel); err != nil {
+			return fmt.Errorf("invalid LOG_STACKTRACE_LEVEL: %w", err)
+		}
+	}
+	if err := validateEncoding("LOG_ENCODING", c.Encoding); err != nil {
+		return err
+	}
+	if c.OutputLevel != "" {
+		if _, err := zapcore.ParseLevel(c.OutputLevel); err != nil {
+			return fmt.Errorf("invalid LOG_OUTPUT_LEVEL: %w", err)
+		}
+	}
+	if err := c.File.validate("LOG_FILE"); err != nil {
+		return err
+	}
+	if err := c.ErrorFile.validate("LOG_ERROR_FILE"); err != nil {
+		return err
+	}
+	if c.Syslog.Enabled {
+		if err := c.Syslog.validate("LOG_SYSLOG"); err != nil {
+			return err
+		}
+	}
+	if c.SamplingInitial < 0 || c.SamplingThereafter < 0 {
+		return fmt.Errorf("sampling values must not be negative")
+	}
+	if c.CallerSkip < 0 {
+		return fmt.Errorf("LOG_CALLER_SKIP must not be negative")
+	}
+	if _, err := c.RedactionPolicy(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// RedactionPolicy returns DefaultRedactionPolicy extended with LOG_REDACT_KEYS and LOG_REDACT_PATTERNS
+func (c LoggerConfig) RedactionPolicy() (RedactionPolicy, error) {
+	policy := DefaultRedactionPolicy()
+	policy.Keys = append(policy.Keys, c.RedactKeys...)
+	for _, pattern := range c.RedactPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return policy, fmt.Errorf("invalid LOG_REDACT_PATTERNS entry %q: %w", pattern, err)
+		}
+		policy.Patterns = append(policy.Patterns, re)
+	}
+	return policy, nil
+}
+
 func InitGlobalLogger(isProd bool) error {
-	var config zap.Config
+	return InitGlobalLoggerWithConfig(LoggerConfig{}, isProd)
+}
+
+// InitGlobalLoggerWithConfig builds a logger from config and replaces the global zap logger.
+// The global logger uses the app level, loggers returned by Logger use their own levels
+// from Levels. Levels can be changed later with SetLogLevel or Levels().SetLevel
+func InitGlobalLoggerWithConfig(config LoggerConfig, isProd bool) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	var zapConfig zap.Config
+	stacktraceLevel := zapcore.ErrorLevel
 	if isProd {
-		config = zap.NewProductionConfig()
+		zapConfig = zap.NewProductionConfig()
+	} else {
+		zapConfig = zap.NewDevelopmentConfig()
+		stacktraceLevel = zapcore.WarnLevel
+	}
+
+	encoding := zapConfig.Encoding
+	if config.Encoding != "" {
+		encoding = config.Encoding
+	}
+	if len(config.OutputPaths) == 0 {
+		config.OutputPaths = zapConfig.OutputPaths
+	}
+	if len(config.ErrorOutputPaths) == 0 {
+		config.ErrorOutputPaths = zapConfig.ErrorOutputPaths
+	}
+
+	sampling := zapConfig.Sampling
+	if config.DisableSampling {
+		sampling = nil
+	} else if config.SamplingInitial > 0 || config.SamplingThereafter > 0 {
+		sampling = &zap.SamplingConfig{
+			Initial:    config.SamplingInitial,
+			Thereafter: config.SamplingThereafter,
+		}
+	}
+
+	if config.StacktraceLevel != "" {
+		stacktraceLevel, _ = zapcore.ParseLevel(config.StacktraceLevel)
+	}
+
+	encoderConfig := zapConfig.EncoderConfig
+	if config.TimeFormat != "" {
+		encoderConfig.EncodeTime = timeEncoder(config.TimeFormat)
+	}
+
+	// Levels are filtered by a wrapping core, the sink cores pass every entry at or above the sink level
+	loggerMu.Lock()
+	defaultGlobalLevel = zapConfig.Level.Level()
+	loggerMu.Unlock()
+	if err := SetLogLevels(config.Level, config.ModuleLevels); err != nil {
+		return err
+	}
+
+	cores, err := buildSinkCores(config, encoding, encoderConfig, !isProd)
+	if err != nil {
+		return fmt.Errorf("build logger: %w", err)
+	}
+	errorOutput, _, err := zap.Open(config.ErrorOutputPaths...)
+	if err != nil {
+		return fmt.Errorf("open log error output: %w", err)
+	}
+
+	// Redaction runs once above the tee, so sinks check their own level on write.
+	// Sampling wraps it, so that dropped entries are not redacted
+	var core zapcore.Core
+	if config.DisableRedaction {
+		core = zapcore.NewTee(cores...)
 	} else {
-		config = zap.NewDevelopmentConfig()
-		config.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		policy, _ := config.RedactionPolicy()
+		for i := range cores {
+			cores[i] = sinkLevelCore{cores[i]}
+		}
+		core = NewRedactingCore(zapcore.NewTee(cores...), policy)
+	}
+	if sampling != nil {
+		core = zapcore.NewSamplerWithOptions(core, time.Second, sampling.Initial, sampling.Thereafter)
+	}
+
+	options := []zap.Option{
+		zap.ErrorOutput(errorOutput),
+		zap.AddCaller(),
+		zap.AddCallerSkip(config.CallerSkip),
+		zap.AddStacktrace(stacktraceLevel),
+		zap.Fields(staticFields(config, isProd)...),
+	}
+	if zapConfig.Development {
+		options = append(options, zap.Development())
+	}
+	logger := zap.New(core, options...)
+
+	setRootLogger(logger)
+	zap.ReplaceGlobals(Logger(LoggerApp))
+	return nil
+}
+
+func timeEncoder(format string) zapcore.TimeEncoder {
+	switch format {
+	case "iso8601":
+		return zapcore.ISO8601TimeEncoder
+	case "rfc3339":
+		return zapcore.RFC3339TimeEncoder
+	case "rfc3339nano":
+		return zapcore.RFC3339NanoTimeEncoder
+	case "epoch":
+		return zapcore.EpochTimeEncoder
+	case "epochmillis":
+		return zapcore.EpochMillisTimeEncoder
+	case "epochnanos":
+		return zapcore.EpochNanosTimeEncoder
+	default:
+		return zapcore.TimeEncoderOfLayout(format)
 	}
+}
+
+func staticFields(config LoggerConfig, isProd bool) []zap.Field {
+	var fields []zap.Field
+	if config.ServiceName != "" {
+		fields = append(fields, zap.String("service", config.ServiceName))
+	}
+	if config.ServiceVersion != "" {
+		fields = append(fields, zap.String("version", config.ServiceVersion))
+	}
+
+	environment := config.Environment
+	if environment == "" {
+		environment = "development"
+		if isProd {
+			environment = "production"
+		}
+	}
+	fields = append(fields, zap.String("environment", environment))
+
+	hostname := config.Hostname
+	if hostname == "" {
+		hostname, _ = os.Hostname()
+	}
+	if hostname != "" {
+		fields = append(fields, zap.String("hostname", hostname))
+	}
+	return fields
+}
+
+// SetLogLevel sets all loggers to level, keeping module levels from LOG_MODULE_LEVELS.
+// An empty level restores the default of the preset
+func SetLogLevel(level string) error {
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
+	return applyLogLevels(level, moduleLevels)
+}
 
-	logger, err := config.Build()
+// SetLogLevels sets all loggers to level and overrides modules with "name=level" pairs
+func SetLogLevels(level string, modules []string) error {
+	parsed, err := parseModuleLevels(modules)
 	if err != nil {
 		return err
 	}
 
-	zap.ReplaceGlobals(logger)
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
+	if err := applyLogLevels(level, parsed); err != nil {
+		return err
+	}
+	moduleLevels = parsed
+	return nil
+}
+
+// applyLogLevels resets the registry to level and module overrides, loggerMu must be held
+func applyLogLevels(level string, modules map[string]zapcore.Level) error {
+	parsed := defaultGlobalLevel
+	if level != "" {
+		var err error
+		if parsed, err = zapcore.ParseLevel(level); err != nil {
+			return err
+		}
+	}
+
+	levels.reset(parsed, modules)
 	return nil
 }
 
@@ -28,5 +307,12 @@ func GetLogger() *zap.Logger {
 }
 
 func SetGlobalLogger(logger *zap.Logger) {
+	setRootLogger(logger)
 	zap.ReplaceGlobals(logger)
 }
+
+func setRootLogger(logger *zap.Logger) {
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
+	rootLogger = logger
+}
@@ -0,0 +1,184 @@
+package gocore
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"log/slog"
+	"runtime"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nk-bm/gocore/goutils"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SlogHandler is a slog.Handler that writes records to a zap core,
+// so slog output gets the same encoders, sinks, redaction and static fields
+type SlogHandler struct {
+	core zapcore.Core
+	name string
+}
+
+// NewSlogHandler returns a handler writing to the core of logger under its name
+func NewSlogHandler(logger *zap.Logger) *SlogHandler {
+	return &SlogHandler{core: logger.Core(), name: logger.Name()}
+}
+
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.core.Enabled(slogToZapLevel(level))
+}
+
+func (h *SlogHandler) Handle(ctx context.Context, record slog.Record) error {
+	entry := zapcore.Entry{
+		Level:      slogToZapLevel(record.Level),
+		Time:       record.Time,
+		LoggerName: h.name,
+		Message:    record.Message,
+	}
+	if record.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		entry.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+	}
+
+	checked := h.core.Check(entry, nil)
+	if checked == nil {
+		return nil
+	}
+
+	contextFields := goutils.LogFieldsFromContext(ctx)
+	fields := make([]zap.Field, 0, len(contextFields)+record.NumAttrs())
+	fields = append(fields, contextFields...)
+	record.Attrs(func(attr slog.Attr) bool {
+		fields = appendSlogAttr(fields, attr)
+		return true
+	})
+	checked.Write(fields...)
+	return nil
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make([]zap.Field, 0, len(attrs))
+	for _, attr := range attrs {
+		fields = appendSlogAttr(fields, attr)
+	}
+	return &SlogHandler{core: h.core.With(fields), name: h.name}
+}
+
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &SlogHandler{core: h.core.With([]zap.Field{zap.Namespace(name)}), name: h.name}
+}
+
+func slogToZapLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level < slog.LevelInfo:
+		return zapcore.DebugLevel
+	case level < slog.LevelWarn:
+		return zapcore.InfoLevel
+	case level < slog.LevelError:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
+}
+
+func appendSlogAttr(fields []zap.Field, attr slog.Attr) []zap.Field {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	switch attr.Value.Kind() {
+	case slog.KindGroup:
+		group := attr.Value.Group()
+		if len(group) == 0 {
+			return fields
+		}
+		// Attributes of a group without a key are inlined, as slog requires
+		if attr.Key == "" {
+			for _, a := range group {
+				fields = appendSlogAttr(fields, a)
+			}
+			return fields
+		}
+		return append(fields, zap.Object(attr.Key, slogGroup(group)))
+	case slog.KindString:
+		return append(fields, zap.String(attr.Key, attr.Value.String()))
+	case slog.KindInt64:
+		return append(fields, zap.Int64(attr.Key, attr.Value.Int64()))
+	case slog.KindUint64:
+		return append(fields, zap.Uint64(attr.Key, attr.Value.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, zap.Float64(attr.Key, attr.Value.Float64()))
+	case slog.KindBool:
+		return append(fields, zap.Bool(attr.Key, attr.Value.Bool()))
+	case slog.KindDuration:
+		return append(fields, zap.Duration(attr.Key, attr.Value.Duration()))
+	case slog.KindTime:
+		return append(fields, zap.Time(attr.Key, attr.Value.Time()))
+	default:
+		if err, ok := attr.Value.Any().(error); ok {
+			return append(fields, zap.NamedError(attr.Key, err))
+		}
+		return append(fields, zap.Any(attr.Key, attr.Value.Any()))
+	}
+}
+
+type slogGroup []slog.Attr
+
+func (g slogGroup) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, field := range appendSlogAttr(nil, slog.Attr{Value: slog.GroupValue(g...)}) {
+		field.AddTo(enc)
+	}
+	return nil
+}
+
+// lineWriter logs every line written to it as a separate entry
+type lineWriter struct {
+	logger *zap.Logger
+	level  zapcore.Level
+	prefix string
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	for _, line := range bytes.Split(p, []byte("\n")) {
+		message := strings.TrimSpace(strings.TrimPrefix(string(line), w.prefix))
+		if message == "" {
+			continue
+		}
+		if ce := w.logger.Check(w.level, message); ce != nil {
+			ce.Write()
+		}
+	}
+	return len(p), nil
+}
+
+// RedirectStdLogs sends output of log/slog, the standard log package and gin's
+// DefaultWriter and DefaultErrorWriter to the module loggers. The returned function restores them
+func RedirectStdLogs() func() {
+	previousSlog := slog.Default()
+	previousOutput, previousFlags, previousPrefix := log.Writer(), log.Flags(), log.Prefix()
+	previousGinWriter, previousGinErrorWriter := gin.DefaultWriter, gin.DefaultErrorWriter
+
+	slog.SetDefault(slog.New(NewSlogHandler(Logger(LoggerStdlib))))
+	// slog.SetDefault also redirects the log package to the handler, RedirectStdLog takes it back
+	// so that log lines get the caller of log.Print rather than of the log package
+	zap.RedirectStdLog(Logger(LoggerStdlib))
+
+	// The caller and stacktrace would point into gin and this writer, not to the code that logged
+	httpLogger := Logger(LoggerHTTP).WithOptions(zap.WithCaller(false), zap.AddStacktrace(zapcore.FatalLevel))
+	gin.DefaultWriter = &lineWriter{logger: httpLogger, level: zapcore.DebugLevel, prefix: "[GIN-debug]"}
+	gin.DefaultErrorWriter = &lineWriter{logger: httpLogger, level: zapcore.ErrorLevel, prefix: "[GIN-debug]"}
+
+	return func() {
+		gin.DefaultWriter, gin.DefaultErrorWriter = previousGinWriter, previousGinErrorWriter
+		slog.SetDefault(previousSlog)
+		log.SetOutput(previousOutput)
+		log.SetFlags(previousFlags)
+		log.SetPrefix(previousPrefix)
+	}
+}
@@ -0,0 +1,89 @@
+package gocore
+
+import (
+	"context"
+	"errors"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nk-bm/gocore/goutils"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSlogHandler(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := slog.New(NewSlogHandler(zap.New(core).Named("stdlib")))
+
+	ctx := goutils.ContextWithLogFields(context.Background(), zap.String("request_id", "req-1"))
+	logger.DebugContext(ctx, "dropped")
+	logger.With("component", "billing").WithGroup("order").InfoContext(ctx, "created",
+		"id", 7, "paid", true, slog.Group("customer", "name", "alice"), "err", errors.New("boom"))
+	logger.WarnContext(ctx, "warn line")
+	logger.Log(ctx, slog.LevelError+4, "critical line")
+
+	entries := logs.All()
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+	created := entries[0]
+	if created.Level != zapcore.InfoLevel || created.LoggerName != "stdlib" || created.Message != "created" {
+		t.Errorf("entry = %+v", created.Entry)
+	}
+	if !strings.HasSuffix(created.Caller.File, "logger_bridge_test.go") {
+		t.Errorf("caller = %s, want the test file", created.Caller.File)
+	}
+
+	fields := created.ContextMap()
+	order, _ := fields["order"].(map[string]any)
+	customer, _ := order["customer"].(map[string]any)
+	if fields["component"] != "billing" || order["id"] != int64(7) ||
+		order["paid"] != true || order["err"] != "boom" || customer["name"] != "alice" {
+		t.Errorf("fields = %v", fields)
+	}
+
+	if entries[1].Level != zapcore.WarnLevel || entries[2].Level != zapcore.ErrorLevel {
+		t.Errorf("levels = %v, %v, want warn and error", entries[1].Level, entries[2].Level)
+	}
+	if got := entries[1].ContextMap()["request_id"]; got != "req-1" {
+		t.Errorf("request_id = %v, want the context field", got)
+	}
+}
+
+func TestRedirectStdLogs(t *testing.T) {
+	path := initTestLogger(t, LoggerConfig{Level: "debug", DisableSampling: true})
+	restore := RedirectStdLogs()
+
+	log.Print("std line")
+	slog.Info("slog line", "password", "p")
+	gin.DefaultWriter.Write([]byte("[GIN-debug] gin line\n\n"))
+	gin.DefaultErrorWriter.Write([]byte("[GIN-debug] gin error\n"))
+	restore()
+	log.Print("restored line")
+
+	lines := readLogLines(t, path)
+	want := []struct{ logger, level, msg string }{
+		{LoggerStdlib, "info", "std line"},
+		{LoggerStdlib, "info", "slog line"},
+		{LoggerHTTP, "debug", "gin line"},
+		{LoggerHTTP, "error", "gin error"},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d: %v", len(lines), len(want), lines)
+	}
+	for i, w := range want {
+		if lines[i]["logger"] != w.logger || lines[i]["level"] != w.level || lines[i]["msg"] != w.msg {
+			t.Errorf("line %d = %v, want %+v", i, lines[i], w)
+		}
+	}
+	if lines[0]["caller"] == nil || !strings.Contains(lines[0]["caller"].(string), "logger_bridge_test.go") {
+		t.Errorf("log.Print caller = %v, want the test file", lines[0]["caller"])
+	}
+	if lines[1]["password"] != defaultRedactionMask {
+		t.Errorf("slog password = %v, want masked", lines[1]["password"])
+	}
+}
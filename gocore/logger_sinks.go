@@ -0,0 +1,153 @@
+package gocore
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// SinkConfig holds settings shared by all additional log sinks
+type SinkConfig struct {
+	Enabled bool `env:"ENABLED; default:false"`
+	// Level is the minimum level written to the sink. Empty writes every level passed by the logger
+	Level string `env:"LEVEL"`
+	// Encoding is json or console. Empty uses LOG_ENCODING
+	Encoding string `env:"ENCODING"`
+}
+
+// FileSinkConfig configures a file sink rotated by size and age
+type FileSinkConfig struct {
+	SinkConfig
+	Path       string `env:"PATH"`
+	MaxSizeMB  int    `env:"MAX_SIZE_MB; default:100"`
+	MaxAgeDays int    `env:"MAX_AGE_DAYS; default:30"`
+	MaxBackups int    `env:"MAX_BACKUPS; default:10"`
+	Compress   bool   `env:"COMPRESS; default:true"`
+}
+
+// SyslogSinkConfig configures a syslog sink. Empty Network and Address use the local syslog daemon
+type SyslogSinkConfig struct {
+	SinkConfig
+	Network string `env:"NETWORK"`
+	Address string `env:"ADDRESS"`
+	// Tag defaults to the service name
+	Tag string `env:"TAG"`
+}
+
+func (c SinkConfig) validate(name string) error {
+	if c.Level != "" {
+		if _, err := zapcore.ParseLevel(c.Level); err != nil {
+			return fmt.Errorf("invalid %s level: %w", name, err)
+		}
+	}
+	return validateEncoding(name, c.Encoding)
+}
+
+func (c SinkConfig) levelEnabler() zapcore.LevelEnabler {
+	if c.Level == "" {
+		return zapcore.DebugLevel
+	}
+	level, _ := zapcore.ParseLevel(c.Level)
+	return level
+}
+
+func (c FileSinkConfig) validate(name string) error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Path == "" {
+		return fmt.Errorf("%s path is required", name)
+	}
+	if c.MaxSizeMB < 0 || c.MaxAgeDays < 0 || c.MaxBackups < 0 {
+		return fmt.Errorf("%s rotation limits must not be negative", name)
+	}
+	return c.SinkConfig.validate(name)
+}
+
+func validateEncoding(name, encoding string) error {
+	switch encoding {
+	case "", "json", "console":
+		return nil
+	}
+	return fmt.Errorf("invalid %s encoding %q: expected json or console", name, encoding)
+}
+
+// buildSinkCores creates a core for the main output and every enabled sink.
+// Colored levels are used only by the main output with console encoding
+func buildSinkCores(config LoggerConfig, encoding string, encoderConfig zapcore.EncoderConfig, colorLevels bool) ([]zapcore.Core, error) {
+	var cores []zapcore.Core
+
+	if !config.DisableOutput {
+		output, _, err := zap.Open(config.OutputPaths...)
+		if err != nil {
+			return nil, fmt.Errorf("open log output: %w", err)
+		}
+		outputConfig := encoderConfig
+		if colorLevels && encoding == "console" {
+			outputConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		}
+		mainSink := SinkConfig{Level: config.OutputLevel}
+		cores = append(cores, zapcore.NewCore(newEncoder(encoding, outputConfig), output, mainSink.levelEnabler()))
+	}
+
+	for _, file := range []FileSinkConfig{config.File, config.ErrorFile} {
+		if !file.Enabled {
+			continue
+		}
+		writer := zapcore.AddSync(&lumberjack.Logger{
+			Filename:   file.Path,
+			MaxSize:    file.MaxSizeMB,
+			MaxAge:     file.MaxAgeDays,
+			MaxBackups: file.MaxBackups,
+			Compress:   file.Compress,
+		})
+		cores = append(cores, zapcore.NewCore(newEncoder(sinkEncoding(file.SinkConfig, encoding), encoderConfig), writer, file.levelEnabler()))
+	}
+
+	if config.Syslog.Enabled {
+		tag := config.Syslog.Tag
+		if tag == "" {
+			tag = config.ServiceName
+		}
+		core, err := newSyslogCore(config.Syslog, tag, newEncoder(sinkEncoding(config.Syslog.SinkConfig, encoding), encoderConfig))
+		if err != nil {
+			return nil, fmt.Errorf("open syslog: %w", err)
+		}
+		cores = append(cores, core)
+	}
+
+	return cores, nil
+}
+
+func sinkEncoding(sink SinkConfig, fallback string) string {
+	if sink.Encoding != "" {
+		return sink.Encoding
+	}
+	return fallback
+}
+
+func newEncoder(encoding string, encoderConfig zapcore.EncoderConfig) zapcore.Encoder {
+	if encoding == "console" {
+		return zapcore.NewConsoleEncoder(encoderConfig)
+	}
+	return zapcore.NewJSONEncoder(encoderConfig)
+}
+
+// sinkLevelCore drops entries below the sink level on Write. A core wrapping the Tee adds itself
+// to the checked entry, so the Tee writes every entry to all sinks
+type sinkLevelCore struct {
+	zapcore.Core
+}
+
+func (c sinkLevelCore) With(fields []zapcore.Field) zapcore.Core {
+	return sinkLevelCore{c.Core.With(fields)}
+}
+
+func (c sinkLevelCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if !c.Enabled(entry.Level) {
+		return nil
+	}
+	return c.Core.Write(entry, fields)
+}
@@ -0,0 +1,85 @@
+package gocore
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestFileSinksWithOwnLevels(t *testing.T) {
+	dir := t.TempDir()
+	allPath := filepath.Join(dir, "all.log")
+	errorPath := filepath.Join(dir, "error.log")
+	path := initTestLogger(t, LoggerConfig{
+		Level:           "debug",
+		OutputLevel:     "warn",
+		DisableSampling: true,
+		File:            FileSinkConfig{SinkConfig: SinkConfig{Enabled: true}, Path: allPath},
+		ErrorFile:       FileSinkConfig{SinkConfig: SinkConfig{Enabled: true, Level: "error"}, Path: errorPath},
+	})
+
+	zap.L().Debug("debug line")
+	zap.L().Warn("warn line")
+	zap.L().Error("error line")
+
+	for _, tt := range []struct {
+		path string
+		want []string
+	}{
+		{path, []string{"warn line", "error line"}},
+		{allPath, []string{"debug line", "warn line", "error line"}},
+		{errorPath, []string{"error line"}},
+	} {
+		lines := readLogLines(t, tt.path)
+		var got []string
+		for _, line := range lines {
+			got = append(got, line["msg"].(string))
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: got %v, want %v", filepath.Base(tt.path), got, tt.want)
+		}
+	}
+}
+
+func TestFileSinkEncoding(t *testing.T) {
+	consolePath := filepath.Join(t.TempDir(), "console.log")
+	initTestLogger(t, LoggerConfig{
+		DisableSampling: true,
+		DisableOutput:   true,
+		File:            FileSinkConfig{SinkConfig: SinkConfig{Enabled: true, Encoding: "console"}, Path: consolePath},
+	})
+
+	zap.L().Info("console line")
+	_ = zap.L().Sync()
+
+	data, err := os.ReadFile(consolePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "\tconsole line") || strings.HasPrefix(string(data), "{") {
+		t.Errorf("file sink = %q, want a console encoded line", data)
+	}
+}
+
+func TestSinkConfigValidation(t *testing.T) {
+	configs := []LoggerConfig{
+		{OutputLevel: "loud"},
+		{File: FileSinkConfig{SinkConfig: SinkConfig{Enabled: true, Level: "loud"}, Path: "app.log"}},
+		{File: FileSinkConfig{SinkConfig: SinkConfig{Enabled: true, Encoding: "xml"}, Path: "app.log"}},
+		{ErrorFile: FileSinkConfig{SinkConfig: SinkConfig{Enabled: true}, Path: "app.log", MaxSizeMB: -1}},
+		{Syslog: SyslogSinkConfig{SinkConfig: SinkConfig{Enabled: true, Level: "loud"}}},
+	}
+	for _, config := range configs {
+		if err := config.Validate(); err == nil {
+			t.Errorf("Validate(%+v) succeeded, want an error", config)
+		}
+	}
+
+	disabled := LoggerConfig{File: FileSinkConfig{SinkConfig: SinkConfig{Level: "loud"}}}
+	if err := disabled.Validate(); err != nil {
+		t.Errorf("disabled sink is validated: %v", err)
+	}
+}
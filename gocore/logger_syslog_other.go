@@ -0,0 +1,13 @@
+//go:build windows || plan9
+
+package gocore
+
+import (
+	"errors"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func newSyslogCore(config SyslogSinkConfig, tag string, encoder zapcore.Encoder) (zapcore.Core, error) {
+	return nil, errors.New("syslog is not supported on this platform")
+}
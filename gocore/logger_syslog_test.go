@@ -0,0 +1,46 @@
+//go:build !windows && !plan9
+
+package gocore
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestSyslogSink(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("udp is not available: %v", err)
+	}
+	defer conn.Close()
+
+	initTestLogger(t, LoggerConfig{
+		DisableSampling: true,
+		DisableOutput:   true,
+		ServiceName:     "orders",
+		Syslog: SyslogSinkConfig{
+			SinkConfig: SinkConfig{Enabled: true, Level: "warn"},
+			Network:    "udp",
+			Address:    conn.LocalAddr().String(),
+		},
+	})
+
+	zap.L().Info("dropped line")
+	zap.L().Error("syslog line")
+
+	buf := make([]byte, 4096)
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := string(buf[:n])
+	// LOG_USER|LOG_ERR
+	if !strings.HasPrefix(message, "<11>") || !strings.Contains(message, "orders") || !strings.Contains(message, "syslog line") {
+		t.Errorf("syslog message = %q", message)
+	}
+}
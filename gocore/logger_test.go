@@ -0,0 +1,116 @@
+package gocore
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// initTestLogger builds the global logger writing JSON lines to a temp file and restores the previous one after the test
+func initTestLogger(t *testing.T, config LoggerConfig) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "app.log")
+	config.OutputPaths = []string{path}
+	config.ErrorOutputPaths = []string{"stderr"}
+	if config.Encoding == "" {
+		config.Encoding = "json"
+	}
+
+	previous := zap.L()
+	t.Cleanup(func() {
+		SetGlobalLogger(previous)
+		_ = SetLogLevels("", nil)
+	})
+	if err := InitGlobalLoggerWithConfig(config, true); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// readLogLines returns the decoded JSON lines written to path
+func readLogLines(t *testing.T, path string) []map[string]any {
+	t.Helper()
+	_ = zap.L().Sync()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var lines []map[string]any
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var line map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("invalid log line %q: %v", scanner.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestInitGlobalLoggerWithConfig(t *testing.T) {
+	path := initTestLogger(t, LoggerConfig{
+		Level:           "debug",
+		DisableSampling: true,
+		TimeFormat:      "rfc3339",
+		ServiceName:     "orders",
+		ServiceVersion:  "1.2.3",
+		Environment:     "staging",
+		Hostname:        "host-1",
+	})
+
+	zap.L().Debug("debug line")
+	zap.L().Info("info line", zap.Int("count", 2))
+
+	lines := readLogLines(t, path)
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	line := lines[1]
+	for key, want := range map[string]any{
+		"msg": "info line", "service": "orders", "version": "1.2.3",
+		"environment": "staging", "hostname": "host-1", "count": float64(2),
+	} {
+		if line[key] != want {
+			t.Errorf("%s = %v, want %v", key, line[key], want)
+		}
+	}
+	if ts, ok := line["ts"].(string); !ok || len(ts) < len("2006-01-02T15:04:05Z") {
+		t.Errorf("ts = %v, want an RFC 3339 string", line["ts"])
+	}
+}
+
+func TestInitGlobalLoggerRejectsInvalidConfig(t *testing.T) {
+	configs := []LoggerConfig{
+		{Level: "verbose"},
+		{Encoding: "xml"},
+		{StacktraceLevel: "loud"},
+		{ModuleLevels: []string{"db"}},
+		{CallerSkip: -1},
+		{SamplingInitial: -1},
+		{File: FileSinkConfig{SinkConfig: SinkConfig{Enabled: true}}},
+		{RedactPatterns: []string{"("}},
+	}
+	for _, config := range configs {
+		if err := InitGlobalLoggerWithConfig(config, true); err == nil {
+			t.Errorf("InitGlobalLoggerWithConfig(%+v) succeeded, want an error", config)
+		}
+	}
+}
+
+func TestLoggerLevelFromConfig(t *testing.T) {
+	path := initTestLogger(t, LoggerConfig{Level: "warn", DisableSampling: true})
+
+	zap.L().Info("dropped")
+	zap.L().Warn("kept")
+
+	lines := readLogLines(t, path)
+	if len(lines) != 1 || lines[0]["msg"] != "kept" {
+		t.Fatalf("got %v, want only the warning", lines)
+	}
+}
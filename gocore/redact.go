@@ -0,0 +1,456 @@
+package gocore
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const defaultRedactionMask = "[REDACTED]"
+
+// RedactionPolicy describes what is masked in log entries
+type RedactionPolicy struct {
+	// Keys are field or map key names whose values are masked. A key matches when it equals the name
+	// or one or more of its segments split by "-", "_", ".", spaces and camelCase do, ignoring case,
+	// so "token" matches "X-TMA-Token" and "refreshToken" but not "tokenizer", and "api_key" matches "X-Api-Key"
+	Keys []string
+	// Patterns are masked inside string values and messages
+	Patterns []*regexp.Regexp
+	// CardNumbers masks digit sequences that pass the Luhn check in values of fields matching CardKeys
+	CardNumbers bool
+	CardKeys    []string
+	Mask        string
+}
+
+var (
+	jwtPattern         = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	emailPattern       = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	phonePattern       = regexp.MustCompile(`\+\d[\d\s\-()]{8,}\d`)
+	tmaInitDataPattern = regexp.MustCompile(`\S*\bhash=[0-9a-fA-F]{64}\S*`)
+	cardPattern        = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+)
+
+// DefaultRedactionPolicy masks credentials, Telegram initData, JWTs, emails, phone and card numbers
+func DefaultRedactionPolicy() RedactionPolicy {
+	return RedactionPolicy{
+		Keys: []string{
+			"password", "passwd", "secret", "token", "authorization",
+			"cookie", "initdata", "apikey",
+		},
+		Patterns:    []*regexp.Regexp{jwtPattern, tmaInitDataPattern, emailPattern, phonePattern},
+		CardNumbers: true,
+		CardKeys:    []string{"card", "pan", "cc_number", "credit_card"},
+		Mask:        defaultRedactionMask,
+	}
+}
+
+// NewRedactingCore wraps core so that every field passed to With and Write is redacted by policy.
+// Struct fields tagged `log:"redact"` are always masked
+func NewRedactingCore(core zapcore.Core, policy RedactionPolicy) zapcore.Core {
+	if policy.Mask == "" {
+		policy.Mask = defaultRedactionMask
+	}
+	policy.Keys = normalizeKeys(policy.Keys)
+	policy.CardKeys = normalizeKeys(policy.CardKeys)
+	return &redactingCore{Core: core, policy: &policy}
+}
+
+type redactingCore struct {
+	zapcore.Core
+	policy *RedactionPolicy
+}
+
+func (c *redactingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactingCore{Core: c.Core.With(c.policy.redactFields(fields)), policy: c.policy}
+}
+
+func (c *redactingCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *redactingCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message = c.policy.redactString(entry.Message)
+	return c.Core.Write(entry, c.policy.redactFields(fields))
+}
+
+func (p *RedactionPolicy) redactFields(fields []zapcore.Field) []zapcore.Field {
+	var result []zapcore.Field
+	for i, field := range fields {
+		redacted, changed := p.redactField(field)
+		if !changed {
+			if result != nil {
+				result = append(result, field)
+			}
+			continue
+		}
+		if result == nil {
+			// Copy on the first change only, so that the slice of the caller is not modified
+			result = make([]zapcore.Field, i, len(fields))
+			copy(result, fields[:i])
+		}
+		result = append(result, redacted)
+	}
+	if result == nil {
+		return fields
+	}
+	return result
+}
+
+func (p *RedactionPolicy) redactField(field zapcore.Field) (zapcore.Field, bool) {
+	switch field.Type {
+	case zapcore.NamespaceType, zapcore.SkipType:
+		return field, false
+	}
+	if p.matchKey(field.Key) {
+		return zap.String(field.Key, p.Mask), true
+	}
+	if p.matchCardKey(field.Key) {
+		var value any
+		switch field.Type {
+		case zapcore.StringType:
+			value = field.String
+		case zapcore.Int64Type, zapcore.Int32Type, zapcore.Uint64Type, zapcore.Uint32Type:
+			value = field.Integer
+		default:
+			value = field.Interface
+		}
+		if redacted, changed := p.redactCardValue(reflect.ValueOf(value)); changed {
+			return zap.Any(field.Key, redacted), true
+		}
+	}
+
+	switch field.Type {
+	case zapcore.StringType:
+		if redacted := p.redactString(field.String); redacted != field.String {
+			return zap.String(field.Key, redacted), true
+		}
+	case zapcore.ByteStringType:
+		value := string(field.Interface.([]byte))
+		if redacted := p.redactString(value); redacted != value {
+			return zap.String(field.Key, redacted), true
+		}
+	case zapcore.ErrorType, zapcore.StringerType:
+		var value string
+		if err, ok := field.Interface.(error); ok {
+			value = err.Error()
+		} else if stringer, ok := field.Interface.(fmt.Stringer); ok {
+			value = stringer.String()
+		}
+		if redacted := p.redactString(value); redacted != value {
+			return zap.String(field.Key, redacted), true
+		}
+	case zapcore.ReflectType:
+		if redacted, changed := p.redactValue(reflect.ValueOf(field.Interface), 0); changed {
+			return zap.Any(field.Key, redacted), true
+		}
+	case zapcore.ObjectMarshalerType, zapcore.InlineMarshalerType, zapcore.ArrayMarshalerType:
+		// Marshal the object to a map and check it like zap.Any values
+		enc := zapcore.NewMapObjectEncoder()
+		field.AddTo(enc)
+		if redacted, changed := p.redactValue(reflect.ValueOf(enc.Fields), 0); changed {
+			if field.Type == zapcore.InlineMarshalerType {
+				return zap.Inline(redactedObject(redacted.(map[string]any))), true
+			}
+			return zap.Any(field.Key, redacted.(map[string]any)[field.Key]), true
+		}
+	}
+	return field, false
+}
+
+const maxRedactionDepth = 16
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// redactValue returns a JSON-like copy of v with sensitive values masked and whether anything was masked
+func (p *RedactionPolicy) redactValue(v reflect.Value, depth int) (any, bool) {
+	if !v.IsValid() {
+		return nil, false
+	}
+	if depth > maxRedactionDepth {
+		return v.Interface(), false
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return v.Interface(), false
+		}
+		if v.Kind() == reflect.Pointer && (v.Type().Implements(jsonMarshalerType) || v.Type().Implements(textMarshalerType)) {
+			return v.Interface(), false
+		}
+		redacted, changed := p.redactValue(v.Elem(), depth+1)
+		if !changed {
+			return v.Interface(), false
+		}
+		return redacted, true
+	case reflect.String:
+		value := v.String()
+		if redacted := p.redactString(value); redacted != value {
+			return redacted, true
+		}
+		return v.Interface(), false
+	case reflect.Struct:
+		if v.Type().Implements(jsonMarshalerType) || v.Type().Implements(textMarshalerType) {
+			return v.Interface(), false
+		}
+		result := make(map[string]any)
+		changed := p.redactStruct(v, result, depth)
+		if !changed {
+			return v.Interface(), false
+		}
+		return result, true
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return v.Interface(), false
+		}
+		result := make(map[string]any, v.Len())
+		changed := false
+		iter := v.MapRange()
+		for iter.Next() {
+			key := iter.Key().String()
+			if p.matchKey(key) {
+				result[key] = p.Mask
+				changed = true
+				continue
+			}
+			if redacted, cardChanged := p.redactCardValue(iter.Value()); cardChanged && p.matchCardKey(key) {
+				result[key] = redacted
+				changed = true
+				continue
+			}
+			value, valueChanged := p.redactValue(iter.Value(), depth+1)
+			result[key] = value
+			changed = changed || valueChanged
+		}
+		if !changed {
+			return v.Interface(), false
+		}
+		return result, true
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface(), false
+		}
+		result := make([]any, v.Len())
+		changed := false
+		for i := 0; i < v.Len(); i++ {
+			value, valueChanged := p.redactValue(v.Index(i), depth+1)
+			result[i] = value
+			changed = changed || valueChanged
+		}
+		if !changed {
+			return v.Interface(), false
+		}
+		return result, true
+	}
+
+	if v.CanInterface() {
+		return v.Interface(), false
+	}
+	return nil, false
+}
+
+// redactStruct fills result with exported fields of v named as encoding/json would name them
+func (p *RedactionPolicy) redactStruct(v reflect.Value, result map[string]any, depth int) bool {
+	changed := false
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			tagName, _, _ := strings.Cut(tag, ",")
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		} else if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			changed = p.redactStruct(v.Field(i), result, depth+1) || changed
+			continue
+		}
+
+		if field.Tag.Get("log") == "redact" || p.matchKey(name) {
+			result[name] = p.Mask
+			changed = true
+			continue
+		}
+		if redacted, cardChanged := p.redactCardValue(v.Field(i)); cardChanged && p.matchCardKey(name) {
+			result[name] = redacted
+			changed = true
+			continue
+		}
+
+		value, valueChanged := p.redactValue(v.Field(i), depth+1)
+		result[name] = value
+		changed = changed || valueChanged
+	}
+	return changed
+}
+
+func (p *RedactionPolicy) redactString(value string) string {
+	if value == "" {
+		return value
+	}
+	for _, pattern := range p.Patterns {
+		value = pattern.ReplaceAllString(value, p.Mask)
+	}
+	return value
+}
+
+// redactCardValue masks card numbers in a string or integer value. Other values are not changed
+func (p *RedactionPolicy) redactCardValue(v reflect.Value) (any, bool) {
+	if !p.CardNumbers || !v.IsValid() {
+		return nil, false
+	}
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+
+	var value string
+	switch v.Kind() {
+	case reflect.String:
+		value = v.String()
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		value = strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint32, reflect.Uint64:
+		value = strconv.FormatUint(v.Uint(), 10)
+	default:
+		return nil, false
+	}
+
+	redacted := cardPattern.ReplaceAllStringFunc(value, func(match string) string {
+		if luhnValid(match) {
+			return p.Mask
+		}
+		return match
+	})
+	if redacted == value {
+		return nil, false
+	}
+	return redacted, true
+}
+
+func (p *RedactionPolicy) matchKey(key string) bool {
+	return matchKeySegments(key, p.Keys)
+}
+
+func (p *RedactionPolicy) matchCardKey(key string) bool {
+	return p.CardNumbers && matchKeySegments(key, p.CardKeys)
+}
+
+// matchKeySegments reports whether the key or a run of its consecutive segments equals one of the
+// normalized names
+func matchKeySegments(key string, names []string) bool {
+	if key == "" || len(names) == 0 {
+		return false
+	}
+	segments := keySegments(key)
+	for i := range segments {
+		joined := ""
+		for _, segment := range segments[i:] {
+			joined += segment
+			if slices.Contains(names, joined) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// keySegments splits a key on separators and camelCase boundaries and lowercases the parts,
+// "X-TMA-Token" gives [x tma token] and "refreshTokenTTL" gives [refresh token ttl]
+func keySegments(key string) []string {
+	var (
+		segments []string
+		current  []rune
+	)
+	runes := []rune(key)
+	flush := func() {
+		if len(current) > 0 {
+			segments = append(segments, strings.ToLower(string(current)))
+			current = current[:0]
+		}
+	}
+	for i, r := range runes {
+		switch {
+		case r == '-' || r == '_' || r == '.' || r == ' ':
+			flush()
+			continue
+		case unicode.IsUpper(r) && i > 0:
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				flush()
+			}
+		}
+		current = append(current, r)
+	}
+	flush()
+	return segments
+}
+
+func normalizeKey(key string) string {
+	return strings.Join(keySegments(key), "")
+}
+
+func normalizeKeys(keys []string) []string {
+	normalized := make([]string, 0, len(keys))
+	for _, key := range keys {
+		normalized = append(normalized, normalizeKey(key))
+	}
+	return normalized
+}
+
+func luhnValid(number string) bool {
+	sum, count := 0, 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		digit := int(c - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+		count++
+	}
+	return count >= 13 && sum%10 == 0
+}
+
+// redactedObject adds already redacted values as inline fields
+type redactedObject map[string]any
+
+func (o redactedObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for key, value := range o {
+		zap.Any(key, value).AddTo(enc)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package gocore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Trace exporters of TracingConfig.Exporter
+const (
+	TracesExporterNone   = "none"
+	TracesExporterOTLP   = "otlp"
+	TracesExporterStdout = "stdout"
+)
+
+// TracingConfig uses the standard OpenTelemetry variable names. The OTLP exporter additionally reads
+// OTEL_EXPORTER_OTLP_ENDPOINT, OTEL_EXPORTER_OTLP_HEADERS and the other OTEL_EXPORTER_OTLP_* variables
+type TracingConfig struct {
+	Exporter string `env:"OTEL_TRACES_EXPORTER; default:none"`
+	// SampleRatio is the share of new traces that are recorded, sampled parents are always followed
+	SampleRatio float64 `env:"OTEL_TRACES_SAMPLER_ARG; default:1"`
+	// ServiceName defaults to the app name
+	ServiceName    string `env:"OTEL_SERVICE_NAME"`
+	ServiceVersion string `env:"OTEL_SERVICE_VERSION"`
+}
+
+func (c TracingConfig) Validate() error {
+	switch c.Exporter {
+	case "", TracesExporterNone, TracesExporterOTLP, TracesExporterStdout:
+	default:
+		return fmt.Errorf("invalid OTEL_TRACES_EXPORTER %q: must be one of none, otlp, stdout", c.Exporter)
+	}
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		return fmt.Errorf("invalid OTEL_TRACES_SAMPLER_ARG %v: must be between 0 and 1", c.SampleRatio)
+	}
+	return nil
+}
+
+// Tracing is the tracer provider installed by InitTracing
+type Tracing struct {
+	Provider *sdktrace.TracerProvider
+}
+
+// InitTracing installs the global tracer provider and the W3C trace context propagator.
+// With the none exporter only the propagator is installed, so incoming trace ids still reach logs
+// and outbound requests, and nil is returned
+func InitTracing(config TracingConfig, serviceName string) (*Tracing, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var processor sdktrace.SpanProcessor
+	switch config.Exporter {
+	case "", TracesExporterNone:
+		return nil, nil
+	case TracesExporterOTLP:
+		exporter, err := otlptracehttp.New(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("create otlp trace exporter: %w", err)
+		}
+		processor = sdktrace.NewBatchSpanProcessor(exporter)
+	case TracesExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, fmt.Errorf("create stdout trace exporter: %w", err)
+		}
+		processor = sdktrace.NewBatchSpanProcessor(exporter)
+	}
+
+	if config.ServiceName != "" {
+		serviceName = config.ServiceName
+	}
+	attributes := []attribute.KeyValue{attribute.String("service.name", serviceName)}
+	if config.ServiceVersion != "" {
+		attributes = append(attributes, attribute.String("service.version", config.ServiceVersion))
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attributes...))
+	if err != nil {
+		return nil, fmt.Errorf("create trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+		sdktrace.WithSpanProcessor(processor),
+	)
+	otel.SetTracerProvider(provider)
+	return &Tracing{Provider: provider}, nil
+}
+
+// Shutdown exports the remaining spans until ctx is done
+func (t *Tracing) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	return errors.Join(t.Provider.ForceFlush(ctx), t.Provider.Shutdown(ctx))
+}
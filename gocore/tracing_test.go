@@ -0,0 +1,39 @@
+package gocore
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracingConfigValidate(t *testing.T) {
+	for _, config := range []TracingConfig{
+		{Exporter: "jaeger", SampleRatio: 1},
+		{Exporter: "memory", SampleRatio: 1},
+		{Exporter: TracesExporterOTLP, SampleRatio: 1.5},
+	} {
+		if err := config.Validate(); err == nil {
+			t.Errorf("Validate(%+v) succeeded, want an error", config)
+		}
+	}
+}
+
+func TestInitTracingNoneInstallsPropagator(t *testing.T) {
+	tracing, err := InitTracing(TracingConfig{Exporter: TracesExporterNone, SampleRatio: 1}, "app")
+	if err != nil || tracing != nil {
+		t.Fatalf("InitTracing = %v, %v, want no provider", tracing, err)
+	}
+
+	// Incoming trace ids still reach logs and outbound requests
+	carrier := propagation.MapCarrier{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), carrier)
+	if got := trace.SpanContextFromContext(ctx).TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace id = %q, want the incoming one", got)
+	}
+	if err := tracing.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown of no provider = %v", err)
+	}
+}
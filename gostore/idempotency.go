@@ -0,0 +1,26 @@
+package gostore
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// IdempotencyRecord is the state of a key. Status is 0 while the first request is in flight
+type IdempotencyRecord struct {
+	Fingerprint string
+	Status      int
+	Header      http.Header
+	Body        []byte
+}
+
+// IdempotencyStore keeps the first response of every key
+type IdempotencyStore interface {
+	// Begin claims the key for a request. If the key is taken, it returns the existing record and false.
+	// Expired records and claims older than lockTimeout are taken over
+	Begin(ctx context.Context, key, fingerprint string, lockTimeout, ttl time.Duration) (*IdempotencyRecord, bool, error)
+	// Complete stores the response of a claimed key
+	Complete(ctx context.Context, key, fingerprint string, record IdempotencyRecord, ttl time.Duration) error
+	// Release drops a claim, so that the request can be retried
+	Release(ctx context.Context, key, fingerprint string) error
+}
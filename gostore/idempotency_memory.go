@@ -0,0 +1,76 @@
+package gostore
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryIdempotencyStore keeps responses in the process, retries reaching another replica are not deduplicated
+type MemoryIdempotencyStore struct {
+	mu        sync.Mutex
+	entries   map[string]*memoryIdempotencyEntry
+	lastSweep time.Time
+}
+
+type memoryIdempotencyEntry struct {
+	record    IdempotencyRecord
+	lockedAt  time.Time
+	expiresAt time.Time
+}
+
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		entries: make(map[string]*memoryIdempotencyEntry),
+	}
+}
+
+func (s *MemoryIdempotencyStore) Begin(_ context.Context, key, fingerprint string, lockTimeout, ttl time.Duration) (*IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastSweep) >= memorySweepInterval {
+		for k, entry := range s.entries {
+			if now.After(entry.expiresAt) {
+				delete(s.entries, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	entry, ok := s.entries[key]
+	abandoned := ok && entry.record.Status == 0 && now.Sub(entry.lockedAt) > lockTimeout
+	if ok && now.Before(entry.expiresAt) && !abandoned {
+		record := entry.record
+		return &record, false, nil
+	}
+
+	s.entries[key] = &memoryIdempotencyEntry{
+		record:    IdempotencyRecord{Fingerprint: fingerprint},
+		lockedAt:  now,
+		expiresAt: now.Add(ttl),
+	}
+	return nil, true, nil
+}
+
+func (s *MemoryIdempotencyStore) Complete(_ context.Context, key, fingerprint string, record IdempotencyRecord, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.entries[key]; ok && entry.record.Fingerprint == fingerprint {
+		entry.record = record
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+	return nil
+}
+
+func (s *MemoryIdempotencyStore) Release(_ context.Context, key, fingerprint string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.entries[key]; ok && entry.record.Status == 0 && entry.record.Fingerprint == fingerprint {
+		delete(s.entries, key)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package gostore
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMemoryIdempotencyStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryIdempotencyStore()
+
+	if _, claimed, _ := s.Begin(ctx, "k", "f1", time.Minute, time.Hour); !claimed {
+		t.Fatal("first Begin did not claim the key")
+	}
+	record, claimed, _ := s.Begin(ctx, "k", "f1", time.Minute, time.Hour)
+	if claimed || record.Status != 0 {
+		t.Fatalf("Begin in flight = %+v, %v, want the in-flight record", record, claimed)
+	}
+
+	// Only the claim of the same fingerprint is completed
+	s.Complete(ctx, "k", "f2", IdempotencyRecord{Fingerprint: "f2", Status: http.StatusOK}, time.Hour)
+	if record, _, _ := s.Begin(ctx, "k", "f1", time.Minute, time.Hour); record.Status != 0 {
+		t.Fatalf("record completed by another fingerprint: %+v", record)
+	}
+
+	s.Complete(ctx, "k", "f1", IdempotencyRecord{Fingerprint: "f1", Status: http.StatusCreated, Body: []byte("created")}, time.Hour)
+	record, claimed, _ = s.Begin(ctx, "k", "f1", time.Minute, time.Hour)
+	if claimed || record.Status != http.StatusCreated || string(record.Body) != "created" {
+		t.Errorf("Begin after Complete = %+v, %v, want the stored response", record, claimed)
+	}
+
+	// Completed records are not released
+	s.Release(ctx, "k", "f1")
+	if _, claimed, _ := s.Begin(ctx, "k", "f1", time.Minute, time.Hour); claimed {
+		t.Error("Release dropped a completed record")
+	}
+}
+
+func TestMemoryIdempotencyStoreRelease(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryIdempotencyStore()
+
+	s.Begin(ctx, "k", "f", time.Minute, time.Hour)
+	s.Release(ctx, "k", "f")
+	if _, claimed, _ := s.Begin(ctx, "k", "f", time.Minute, time.Hour); !claimed {
+		t.Error("released key was not claimed again")
+	}
+}
+
+func TestMemoryIdempotencyStoreExpiry(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryIdempotencyStore()
+
+	// A claim older than the lock timeout belongs to a lost request
+	s.Begin(ctx, "lost", "f", time.Millisecond, time.Hour)
+	s.Begin(ctx, "done", "f", time.Minute, time.Millisecond)
+	s.Complete(ctx, "done", "f", IdempotencyRecord{Fingerprint: "f", Status: http.StatusOK}, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	if _, claimed, _ := s.Begin(ctx, "lost", "f", time.Millisecond, time.Hour); !claimed {
+		t.Error("abandoned claim was not taken over")
+	}
+	if _, claimed, _ := s.Begin(ctx, "done", "f", time.Minute, time.Hour); !claimed {
+		t.Error("expired record was not taken over")
+	}
+}
@@ -0,0 +1,156 @@
+// Package gostore holds the state that replicas may share: rate limits and idempotent responses.
+// It has in-memory stores, dbcore has Postgres stores and gincore/ginmw applies them to requests
+package gostore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	AlgorithmTokenBucket   = "token_bucket"
+	AlgorithmSlidingWindow = "sliding_window"
+)
+
+// RateLimit allows Limit requests per Window
+type RateLimit struct {
+	Algorithm string
+	Limit     int
+	Window    time.Duration
+	// Burst is the token bucket capacity, Limit if zero
+	Burst int
+}
+
+func (l RateLimit) Validate() error {
+	switch l.Algorithm {
+	case AlgorithmTokenBucket, AlgorithmSlidingWindow:
+	default:
+		return fmt.Errorf("unknown rate limit algorithm %q", l.Algorithm)
+	}
+	if l.Limit <= 0 || l.Window <= 0 || l.Burst < 0 {
+		return errors.New("rate limit and window must be positive")
+	}
+	return nil
+}
+
+func (l RateLimit) capacity() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Limit
+}
+
+// TTL is how long the state of an idle key affects later requests
+func (l RateLimit) TTL() time.Duration {
+	refill := time.Duration(float64(l.Window) * float64(l.capacity()) / float64(l.Limit))
+	return max(2*l.Window, refill)
+}
+
+// RateLimitState is what a store keeps per key
+type RateLimitState struct {
+	// Tokens is the token bucket level
+	Tokens float64
+	// Current and Previous count requests of the current and previous sliding windows
+	Current     int
+	Previous    int
+	WindowStart time.Time
+	UpdatedAt   time.Time
+}
+
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is when the limit is fully available again
+	Reset time.Duration
+	// RetryAfter is when a denied request may be retried
+	RetryAfter time.Duration
+}
+
+// Take counts a request at now against state, updating it. Stores call it under a per-key lock
+func (l RateLimit) Take(state *RateLimitState, now time.Time) RateLimitResult {
+	if l.Algorithm == AlgorithmSlidingWindow {
+		return l.takeSlidingWindow(state, now)
+	}
+	return l.takeTokenBucket(state, now)
+}
+
+// Peek returns what Take would return without changing state
+func (l RateLimit) Peek(state RateLimitState, now time.Time) RateLimitResult {
+	return l.Take(&state, now)
+}
+
+func (l RateLimit) takeTokenBucket(state *RateLimitState, now time.Time) RateLimitResult {
+	capacity := float64(l.capacity())
+	rate := float64(l.Limit) / l.Window.Seconds()
+
+	if state.UpdatedAt.IsZero() {
+		state.Tokens = capacity
+	} else if elapsed := now.Sub(state.UpdatedAt).Seconds(); elapsed > 0 {
+		state.Tokens = math.Min(capacity, state.Tokens+elapsed*rate)
+	}
+	state.UpdatedAt = now
+
+	result := RateLimitResult{Limit: l.capacity()}
+	if state.Tokens >= 1 {
+		state.Tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - state.Tokens) / rate)
+	}
+	result.Remaining = int(state.Tokens)
+	result.Reset = seconds((capacity - state.Tokens) / rate)
+	return result
+}
+
+// takeSlidingWindow approximates the count of the last Window by weighting the previous fixed window
+func (l RateLimit) takeSlidingWindow(state *RateLimitState, now time.Time) RateLimitResult {
+	windowStart := now.Truncate(l.Window)
+	switch {
+	case state.WindowStart.Equal(windowStart):
+	case state.WindowStart.Add(l.Window).Equal(windowStart):
+		state.Previous, state.Current = state.Current, 0
+	default:
+		state.Previous, state.Current = 0, 0
+	}
+	state.WindowStart = windowStart
+	state.UpdatedAt = now
+
+	elapsed := now.Sub(windowStart)
+	toWindowEnd := l.Window - elapsed
+	weight := 1 - float64(elapsed)/float64(l.Window)
+	count := float64(state.Previous)*weight + float64(state.Current)
+
+	result := RateLimitResult{Limit: l.Limit, Reset: toWindowEnd}
+	if count+1 <= float64(l.Limit) {
+		state.Current++
+		result.Allowed = true
+		result.Remaining = max(0, l.Limit-int(math.Ceil(count+1)))
+		return result
+	}
+
+	if state.Current >= l.Limit {
+		// The count falls under the limit once the current window is old enough
+		result.RetryAfter = toWindowEnd + time.Duration(float64(l.Window)*(1-float64(l.Limit-1)/float64(state.Current)))
+	} else {
+		needed := 1 - float64(l.Limit-1-state.Current)/float64(state.Previous)
+		result.RetryAfter = max(0, time.Duration(float64(l.Window)*needed)-elapsed)
+	}
+	result.Reset = max(result.Reset, result.RetryAfter)
+	return result
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// RateLimitStore keeps rate limit state per key
+type RateLimitStore interface {
+	// Take counts a request under key
+	Take(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error)
+	// Peek reports whether a request under key would be allowed, without counting it
+	Peek(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error)
+}
@@ -0,0 +1,63 @@
+package gostore
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryRateLimitStore keeps rate limit state in the process, limits are per replica
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	entries   map[string]*memoryRateLimitEntry
+	lastSweep time.Time
+}
+
+type memoryRateLimitEntry struct {
+	state     RateLimitState
+	expiresAt time.Time
+}
+
+// memorySweepInterval is how often expired keys are removed
+const memorySweepInterval = time.Minute
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		entries: make(map[string]*memoryRateLimitEntry),
+	}
+}
+
+func (s *MemoryRateLimitStore) Take(_ context.Context, key string, limit RateLimit) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastSweep) >= memorySweepInterval {
+		for k, entry := range s.entries {
+			if now.After(entry.expiresAt) {
+				delete(s.entries, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	entry, ok := s.entries[key]
+	if !ok {
+		entry = &memoryRateLimitEntry{}
+		s.entries[key] = entry
+	}
+	result := limit.Take(&entry.state, now)
+	entry.expiresAt = now.Add(limit.TTL())
+	return result, nil
+}
+
+func (s *MemoryRateLimitStore) Peek(_ context.Context, key string, limit RateLimit) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var state RateLimitState
+	if entry, ok := s.entries[key]; ok && time.Now().Before(entry.expiresAt) {
+		state = entry.state
+	}
+	return limit.Peek(state, time.Now()), nil
+}
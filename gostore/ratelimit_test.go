@@ -0,0 +1,92 @@
+package gostore
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	limit := RateLimit{Algorithm: AlgorithmTokenBucket, Limit: 2, Window: time.Second, Burst: 3}
+	var state RateLimitState
+	now := time.Unix(1000, 0)
+
+	for i := range 3 {
+		if result := limit.Take(&state, now); !result.Allowed || result.Remaining != 2-i || result.Limit != 3 {
+			t.Fatalf("request %d = %+v, want allowed within the burst", i, result)
+		}
+	}
+	result := limit.Take(&state, now)
+	if result.Allowed || result.RetryAfter != 500*time.Millisecond {
+		t.Fatalf("request over the burst = %+v, want denied with RetryAfter 500ms", result)
+	}
+
+	// Two tokens are refilled per second
+	if result := limit.Take(&state, now.Add(500*time.Millisecond)); !result.Allowed {
+		t.Errorf("request after refill = %+v, want allowed", result)
+	}
+	if result := limit.Take(&state, now.Add(10*time.Second)); !result.Allowed || result.Remaining != 2 {
+		t.Errorf("request after idle = %+v, want a full bucket", result)
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	limit := RateLimit{Algorithm: AlgorithmSlidingWindow, Limit: 4, Window: time.Minute}
+	var state RateLimitState
+	start := time.Unix(6000, 0) // the start of a window
+
+	for i := range 4 {
+		if result := limit.Take(&state, start.Add(time.Duration(i)*time.Second)); !result.Allowed {
+			t.Fatalf("request %d = %+v, want allowed", i, result)
+		}
+	}
+	if result := limit.Take(&state, start.Add(5*time.Second)); result.Allowed || result.RetryAfter <= 0 {
+		t.Fatalf("request over the limit = %+v, want denied", result)
+	}
+
+	// Half way through the next window the previous one weighs 2 requests
+	middle := start.Add(time.Minute + 30*time.Second)
+	for i := range 2 {
+		if result := limit.Take(&state, middle); !result.Allowed {
+			t.Fatalf("request %d of the next window = %+v, want allowed", i, result)
+		}
+	}
+	if result := limit.Take(&state, middle); result.Allowed {
+		t.Errorf("request over the weighted limit = %+v, want denied", result)
+	}
+}
+
+func TestPeekDoesNotCount(t *testing.T) {
+	limit := RateLimit{Algorithm: AlgorithmTokenBucket, Limit: 1, Window: time.Minute}
+	store := NewMemoryRateLimitStore()
+	ctx := context.Background()
+
+	for range 3 {
+		if result, _ := store.Peek(ctx, "key", limit); !result.Allowed {
+			t.Fatalf("Peek = %+v, want allowed", result)
+		}
+	}
+	if result, _ := store.Take(ctx, "key", limit); !result.Allowed {
+		t.Fatalf("Take = %+v, want allowed", result)
+	}
+	if result, _ := store.Peek(ctx, "key", limit); result.Allowed {
+		t.Errorf("Peek after the limit = %+v, want denied", result)
+	}
+	if result, _ := store.Take(ctx, "other", limit); !result.Allowed {
+		t.Errorf("Take of another key = %+v, want allowed", result)
+	}
+}
+
+func TestRateLimitValidate(t *testing.T) {
+	invalid := []RateLimit{
+		{Algorithm: "leaky_bucket", Limit: 1, Window: time.Second},
+		{Algorithm: AlgorithmTokenBucket, Limit: 0, Window: time.Second},
+		{Algorithm: AlgorithmTokenBucket, Limit: 1, Window: 0},
+		{Algorithm: AlgorithmSlidingWindow, Limit: 1, Window: time.Second, Burst: -1},
+	}
+	for _, limit := range invalid {
+		if err := limit.Validate(); err == nil {
+			t.Errorf("Validate(%+v) succeeded, want an error", limit)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package goutils
+
+import (
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// Build metadata set with ldflags, for example
+//
+//	go build -ldflags "-X github.com/nk-bm/gocore/goutils.Version=1.2.0 -X github.com/nk-bm/gocore/goutils.Commit=$(git rev-parse HEAD)"
+//
+// Empty Version and Commit are taken from the build information embedded by the Go toolchain.
+// BuildTime is only set with ldflags, the toolchain records the commit time instead
+var (
+	Version   string
+	Commit    string
+	BuildTime string
+)
+
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildTime string `json:"build_time,omitempty"`
+	// CommitTime is the time of the commit the binary is built from
+	CommitTime string `json:"commit_time,omitempty"`
+	// Modified reports uncommitted changes in the working tree of the build
+	Modified  bool     `json:"modified,omitempty"`
+	GoVersion string   `json:"go_version"`
+	Module    string   `json:"module,omitempty"`
+	Modules   []Module `json:"modules,omitempty"`
+}
+
+type Module struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	// Replace is the path of the replacement module, if any
+	Replace string `json:"replace,omitempty"`
+}
+
+// GetBuildInfo returns the build metadata of the running binary
+func GetBuildInfo() BuildInfo {
+	return loadBuildInfo()
+}
+
+var loadBuildInfo = sync.OnceValue(func() BuildInfo {
+	build, _ := debug.ReadBuildInfo()
+	return newBuildInfo(build)
+})
+
+// newBuildInfo merges the ldflags values with build, which is nil when the binary has no build information
+func newBuildInfo(build *debug.BuildInfo) BuildInfo {
+	info := BuildInfo{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+
+	if build != nil {
+		info.Module = build.Main.Path
+		if info.Version == "" && build.Main.Version != "(devel)" {
+			info.Version = build.Main.Version
+		}
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.time":
+				info.CommitTime = setting.Value
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+		for _, dep := range build.Deps {
+			module := Module{Path: dep.Path, Version: dep.Version}
+			if dep.Replace != nil {
+				module.Replace = dep.Replace.Path
+				module.Version = dep.Replace.Version
+			}
+			info.Modules = append(info.Modules, module)
+		}
+	}
+
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	return info
+}
@@ -0,0 +1,50 @@
+package goutils
+
+import (
+	"runtime/debug"
+	"testing"
+)
+
+func TestNewBuildInfo(t *testing.T) {
+	info := newBuildInfo(&debug.BuildInfo{
+		Main: debug.Module{Path: "example.com/service", Version: "v1.4.0"},
+		Deps: []*debug.Module{
+			{Path: "github.com/gin-gonic/gin", Version: "v1.10.0"},
+			{Path: "example.com/fork", Version: "v0.1.0", Replace: &debug.Module{Path: "../fork", Version: ""}},
+		},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "abc123"},
+			{Key: "vcs.time", Value: "2026-10-01T12:00:00Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	})
+
+	if info.Version != "v1.4.0" || info.Commit != "abc123" || !info.Modified || info.Module != "example.com/service" {
+		t.Errorf("info = %+v", info)
+	}
+	// The toolchain records the commit time, not the build time
+	if info.CommitTime != "2026-10-01T12:00:00Z" || info.BuildTime != "" {
+		t.Errorf("commit time %q, build time %q, want only the commit time", info.CommitTime, info.BuildTime)
+	}
+	if len(info.Modules) != 2 || info.Modules[1].Replace != "../fork" {
+		t.Errorf("modules = %+v", info.Modules)
+	}
+}
+
+func TestNewBuildInfoLdflags(t *testing.T) {
+	Version, Commit, BuildTime = "1.2.0", "def456", "2026-10-02T08:00:00Z"
+	defer func() { Version, Commit, BuildTime = "", "", "" }()
+
+	info := newBuildInfo(&debug.BuildInfo{
+		Main:     debug.Module{Version: "(devel)"},
+		Settings: []debug.BuildSetting{{Key: "vcs.revision", Value: "abc123"}, {Key: "vcs.time", Value: "2026-10-01T12:00:00Z"}},
+	})
+	if info.Version != "1.2.0" || info.Commit != "def456" || info.BuildTime != "2026-10-02T08:00:00Z" {
+		t.Errorf("info = %+v, want the ldflags values", info)
+	}
+
+	Version = ""
+	if info := newBuildInfo(nil); info.Version != "dev" {
+		t.Errorf("version without build information = %q, want dev", info.Version)
+	}
+}
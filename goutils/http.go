@@ -0,0 +1,24 @@
+package goutils
+
+import (
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// NewHTTPClient returns a client that creates a span for every outbound request and
+// propagates the trace context of the request context in the traceparent header
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	return InstrumentHTTPClient(&http.Client{Timeout: timeout})
+}
+
+// InstrumentHTTPClient wraps the transport of client with tracing and returns the client
+func InstrumentHTTPClient(client *http.Client) *http.Client {
+	transport := client.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	client.Transport = otelhttp.NewTransport(transport)
+	return client
+}
@@ -0,0 +1,51 @@
+package goutils
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type logFieldsKey struct{}
+
+type loggerKey struct{}
+
+// ContextWithLogFields returns a copy of ctx carrying fields in addition to the fields already in ctx
+func ContextWithLogFields(ctx context.Context, fields ...zap.Field) context.Context {
+	existing := LogFieldsFromContext(ctx)
+	merged := make([]zap.Field, 0, len(existing)+len(fields))
+	merged = append(merged, existing...)
+	merged = append(merged, fields...)
+	return context.WithValue(ctx, logFieldsKey{}, merged)
+}
+
+// LogFieldsFromContext returns request-scoped log fields such as request_id
+func LogFieldsFromContext(ctx context.Context) []zap.Field {
+	fields, _ := ctx.Value(logFieldsKey{}).([]zap.Field)
+	return fields
+}
+
+func ContextWithLogger(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// LoggerFromContext returns the request-scoped logger or the global logger
+func LoggerFromContext(ctx context.Context) *zap.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*zap.Logger); ok {
+		return logger
+	}
+	return zap.L()
+}
+
+// TraceFields returns trace_id and span_id of the span in ctx, or nil if ctx has no valid span
+func TraceFields(ctx context.Context) []zap.Field {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return nil
+	}
+	return []zap.Field{
+		zap.String("trace_id", spanContext.TraceID().String()),
+		zap.String("span_id", spanContext.SpanID().String()),
+	}
+}